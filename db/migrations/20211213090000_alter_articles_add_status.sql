@@ -0,0 +1,11 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN status varchar(20) NOT NULL DEFAULT 'published';
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP COLUMN status;
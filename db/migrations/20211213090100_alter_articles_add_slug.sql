@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN slug varchar(255) NOT NULL DEFAULT '',
+  ADD INDEX idx_articles_slug (slug);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP INDEX idx_articles_slug,
+  DROP COLUMN slug;
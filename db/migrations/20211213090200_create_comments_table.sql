@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE comments (
+  id int not null auto_increment,
+  article_id int not null,
+  body text not null,
+  created datetime,
+  PRIMARY KEY(id),
+  FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE comments;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE writers
+  ADD COLUMN email varchar(255) null;
+
+update writers set email = CONCAT('writer', id, '@example.com') where email is null;
+
+ALTER TABLE writers
+  MODIFY COLUMN email varchar(255) NOT NULL,
+  ADD UNIQUE INDEX uq_writers_email (email);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE writers
+  DROP INDEX uq_writers_email,
+  DROP COLUMN email;
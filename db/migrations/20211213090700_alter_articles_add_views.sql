@@ -0,0 +1,21 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN views int NOT NULL DEFAULT 0;
+
+CREATE TABLE recent_views (
+  article_id int not null,
+  visitor_token varchar(64) not null,
+  viewed_at datetime not null,
+  PRIMARY KEY(article_id, visitor_token),
+  FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE recent_views;
+
+ALTER TABLE articles
+  DROP COLUMN views;
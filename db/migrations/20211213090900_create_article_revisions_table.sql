@@ -0,0 +1,19 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE article_revisions (
+  id int not null auto_increment,
+  article_id int not null,
+  revision int not null,
+  title varchar(100) not null,
+  body mediumtext not null,
+  created datetime not null,
+  PRIMARY KEY(id),
+  UNIQUE KEY uq_article_revisions (article_id, revision),
+  FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE article_revisions;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE article_likes (
+  article_id int not null,
+  visitor_token varchar(64) not null,
+  created datetime not null,
+  PRIMARY KEY(article_id, visitor_token),
+  FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE article_likes;
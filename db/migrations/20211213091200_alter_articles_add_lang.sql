@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN lang varchar(10) NOT NULL DEFAULT 'ja',
+  ADD INDEX idx_articles_lang (lang);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP INDEX idx_articles_lang,
+  DROP COLUMN lang;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN tags_cache varchar(1024) NOT NULL DEFAULT '';
+
+update articles set tags_cache = COALESCE((
+  SELECT GROUP_CONCAT(tags.name ORDER BY tags.id SEPARATOR ',')
+  FROM articles_tags AS at
+  INNER JOIN tags ON tags.id = at.tag_id
+  WHERE at.article_id = articles.id
+), '');
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP COLUMN tags_cache;
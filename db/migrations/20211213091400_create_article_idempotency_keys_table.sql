@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE article_idempotency_keys (
+  writer_id int not null,
+  idempotency_key varchar(255) not null,
+  article_id int not null,
+  created datetime not null,
+  PRIMARY KEY(writer_id, idempotency_key),
+  FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE article_idempotency_keys;
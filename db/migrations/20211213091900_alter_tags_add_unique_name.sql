@@ -0,0 +1,36 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+update ignore articles_tags
+  inner join tags on tags.id = articles_tags.tag_id
+  inner join (
+    select name, min(id) as id from tags group by name having count(*) > 1
+  ) as kept on kept.name = tags.name and kept.id <> tags.id
+set articles_tags.tag_id = kept.id;
+
+delete articles_tags from articles_tags
+  inner join tags on tags.id = articles_tags.tag_id
+  inner join (
+    select name, min(id) as id from tags group by name having count(*) > 1
+  ) as kept on kept.name = tags.name and kept.id <> tags.id;
+
+delete tags from tags
+  inner join (
+    select name, min(id) as id from tags group by name having count(*) > 1
+  ) as kept on kept.name = tags.name and kept.id <> tags.id;
+
+update articles set tags_cache = COALESCE((
+  SELECT GROUP_CONCAT(tags.name ORDER BY tags.id SEPARATOR ',')
+  FROM articles_tags AS at
+  INNER JOIN tags ON tags.id = at.tag_id
+  WHERE at.article_id = articles.id
+), '');
+
+ALTER TABLE tags
+  ADD UNIQUE INDEX uq_tags_name (name);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE tags
+  DROP INDEX uq_tags_name;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE autosaves (
+  writer_id int not null,
+  article_id int not null,
+  title varchar(255) not null,
+  body text not null,
+  updated datetime not null,
+  PRIMARY KEY(writer_id, article_id),
+  FOREIGN KEY(writer_id) REFERENCES writers(id),
+  FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE autosaves;
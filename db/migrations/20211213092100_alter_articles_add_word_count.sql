@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN word_count int NOT NULL DEFAULT 0,
+  ADD INDEX idx_articles_word_count (word_count);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP INDEX idx_articles_word_count,
+  DROP COLUMN word_count;
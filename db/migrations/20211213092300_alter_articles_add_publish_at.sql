@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN publish_at datetime NULL DEFAULT NULL,
+  ADD INDEX idx_articles_publish_at (publish_at);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP INDEX idx_articles_publish_at,
+  DROP COLUMN publish_at;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE series (
+  id int not null auto_increment,
+  title varchar(100) not null,
+  created datetime not null,
+  PRIMARY KEY(id)
+);
+
+CREATE TABLE article_series (
+  article_id int not null,
+  series_id int not null,
+  position int not null,
+  PRIMARY KEY(series_id, article_id),
+  INDEX idx_article_series_article_id (article_id),
+  FOREIGN KEY(article_id) REFERENCES articles(id),
+  FOREIGN KEY(series_id) REFERENCES series(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE article_series;
+DROP TABLE series;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN content_hash char(64) NOT NULL DEFAULT '',
+  ADD INDEX idx_articles_content_hash (content_hash);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP INDEX idx_articles_content_hash,
+  DROP COLUMN content_hash;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE slug_aliases (
+  slug varchar(255) not null,
+  article_id int not null,
+  created datetime not null,
+  PRIMARY KEY(slug),
+  INDEX idx_slug_aliases_article_id (article_id),
+  FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE slug_aliases;
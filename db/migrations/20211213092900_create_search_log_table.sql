@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE search_log (
+  id int not null auto_increment,
+  keyword varchar(255) not null,
+  result_count int not null,
+  created datetime not null,
+  PRIMARY KEY(id),
+  INDEX idx_search_log_keyword (keyword)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE search_log;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE follows (
+  follower_id int not null,
+  writer_id int not null,
+  created datetime not null,
+  PRIMARY KEY(follower_id, writer_id),
+  INDEX idx_follows_writer_id (writer_id),
+  FOREIGN KEY(follower_id) REFERENCES writers(id),
+  FOREIGN KEY(writer_id) REFERENCES writers(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE follows;
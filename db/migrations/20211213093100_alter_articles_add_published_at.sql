@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+ALTER TABLE articles
+  ADD COLUMN published_at datetime NULL DEFAULT NULL,
+  ADD INDEX idx_articles_published_at (published_at);
+
+-- 既に公開されている記事は、作成日時を公開日時とします。
+UPDATE articles SET published_at = created WHERE status = 'published';
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP INDEX idx_articles_published_at,
+  DROP COLUMN published_at;
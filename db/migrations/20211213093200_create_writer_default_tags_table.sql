@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE writer_default_tags (
+  writer_id int not null,
+  tag_id int not null,
+  PRIMARY KEY(writer_id, tag_id),
+  FOREIGN KEY(writer_id) REFERENCES writers(id),
+  FOREIGN KEY(tag_id) REFERENCES tags(id)
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE writer_default_tags;
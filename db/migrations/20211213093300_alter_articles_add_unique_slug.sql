@@ -0,0 +1,19 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+update articles
+  inner join (
+    select slug, min(id) as id from articles where slug <> '' group by slug having count(*) > 1
+  ) as duplicated on duplicated.slug = articles.slug and duplicated.id <> articles.id
+set articles.slug = CONCAT(articles.slug, '-', articles.id);
+
+ALTER TABLE articles
+  ADD COLUMN slug_key varchar(255) GENERATED ALWAYS AS (NULLIF(slug, '')) VIRTUAL,
+  ADD UNIQUE INDEX uq_articles_slug (slug_key);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE articles
+  DROP INDEX uq_articles_slug,
+  DROP COLUMN slug_key;
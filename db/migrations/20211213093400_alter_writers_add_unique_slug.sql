@@ -0,0 +1,19 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+update writers
+  inner join (
+    select slug, min(id) as id from writers where slug <> '' group by slug having count(*) > 1
+  ) as duplicated on duplicated.slug = writers.slug and duplicated.id <> writers.id
+set writers.slug = CONCAT(writers.slug, '-', writers.id);
+
+ALTER TABLE writers
+  ADD COLUMN slug_key varchar(255) GENERATED ALWAYS AS (NULLIF(slug, '')) VIRTUAL,
+  ADD UNIQUE INDEX uq_writers_slug (slug_key);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE writers
+  DROP INDEX uq_writers_slug,
+  DROP COLUMN slug_key;
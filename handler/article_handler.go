@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -22,15 +23,16 @@ func ArticleIndex(c echo.Context) error {
 	}
 
 	// リポジトリの処理を呼び出して記事の一覧データを取得します。
-	articles, err := repository.ArticleListByCursor(0)
+	// 次のページがあるかも合わせて取得し、ない場合はもっとみるボタンを表示しません。
+	articles, hasNext, err := repository.ArticleListByCursorWithNext(0)
 
 	// エラーが発生した場合
 	if err != nil {
 		// エラー内容をサーバーのログに出力します。
 		c.Logger().Error(err.Error())
 
-		// クライアントにステータスコード 500 でレスポンスを返します。
-		return c.NoContent(http.StatusInternalServerError)
+		// クライアントにステータスコード 500（DB に接続できない場合は 503）でレスポンスを返します。
+		return c.NoContent(errorStatus(err))
 	}
 
 	// 取得できた最後の記事の ID をカーソルとして設定します。
@@ -43,6 +45,7 @@ func ArticleIndex(c echo.Context) error {
 	data := map[string]interface{}{
 		"Articles": articles,
 		"Cursor":   cursor,
+		"HasNext":  hasNext,
 	}
 
 	// テンプレートファイルとデータを指定して HTML を生成し、クライアントに返却します
@@ -68,12 +71,17 @@ func ArticleShow(c echo.Context) error {
 	// 記事データを取得します。
 	article, err := repository.ArticleGetByID(id)
 
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		// 記事が存在しない場合や公開中でない場合はステータスコード 404 でレスポンスを返却します。
+		return c.NoContent(http.StatusNotFound)
+	}
+
 	if err != nil {
 		// エラー内容をサーバーのログに出力します。
 		c.Logger().Error(err.Error())
 
-		// ステータスコード 500 でレスポンスを返却します。
-		return c.NoContent(http.StatusInternalServerError)
+		// ステータスコード 500（DB に接続できない場合は 503）でレスポンスを返却します。
+		return c.NoContent(errorStatus(err))
 	}
 
 	// テンプレートに渡すデータを map に格納します。
@@ -85,6 +93,33 @@ func ArticleShow(c echo.Context) error {
 	return render(c, "article/show.html", data)
 }
 
+// ArticlePreview ...
+func ArticlePreview(c echo.Context) error {
+	// パスパラメータからプレビュー用のトークンを取得し、下書きを含めて記事データを取得します。
+	article, err := repository.ArticleGetByPreviewToken(c.Param("token"))
+
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		// トークンに一致する記事がない場合はステータスコード 404 でレスポンスを返却します。
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		// エラー内容をサーバーのログに出力します。
+		c.Logger().Error(err.Error())
+
+		// ステータスコード 500（DB に接続できない場合は 503）でレスポンスを返却します。
+		return c.NoContent(errorStatus(err))
+	}
+
+	// テンプレートに渡すデータを map に格納します。
+	data := map[string]interface{}{
+		"Article": article,
+	}
+
+	// 詳細画面と同じテンプレートで HTML を生成し、クライアントに返却します。
+	return render(c, "article/show.html", data)
+}
+
 // ArticleEdit ...
 func ArticleEdit(c echo.Context) error {
 	// パスパラメータから記事 ID を取得します。
@@ -92,14 +127,20 @@ func ArticleEdit(c echo.Context) error {
 	id, _ := strconv.Atoi(c.Param("articleID"))
 
 	// 編集フォームの初期値として表示するために記事データを取得します。
-	article, err := repository.ArticleGetByID(id)
+	// 下書きも編集できるよう、公開中の記事に限らず取得します。
+	article, err := repository.ArticleGetForEdit(id)
+
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		// 記事が存在しない場合はステータスコード 404 でレスポンスを返却します。
+		return c.NoContent(http.StatusNotFound)
+	}
 
 	if err != nil {
 		// エラー内容をサーバーのログに出力します。
 		c.Logger().Error(err.Error())
 
-		// ステータスコード 500 でレスポンスを返却します。
-		return c.NoContent(http.StatusInternalServerError)
+		// ステータスコード 500（DB に接続できない場合は 503）でレスポンスを返却します。
+		return c.NoContent(errorStatus(err))
 	}
 
 	// テンプレートに渡すデータを map に格納します。
@@ -136,12 +177,12 @@ func ArticleCreate(c echo.Context) error {
 	}
 
 	// バリデーションチェックを実行します。
-	if err := c.Validate(&article); err != nil {
+	if err := article.Validate(); err != nil {
 		// エラーの内容をサーバーのログに出力します。
 		c.Logger().Error(err.Error())
 
 		// エラー内容を検査してカスタムエラーメッセージを取得します。
-		out.ValidationErrors = article.ValidationErrors(err)
+		out.ValidationErrors = validationMessages(err)
 
 		// 解釈できたパラメータが許可されていない値の場合は 422 エラーを返却します。
 		return c.JSON(http.StatusUnprocessableEntity, out)
@@ -153,8 +194,8 @@ func ArticleCreate(c echo.Context) error {
 		// エラーの内容をサーバーのログに出力します。
 		c.Logger().Error(err.Error())
 
-		// サーバー内の処理でエラーが発生した場合は 500 エラーを返却します。
-		return c.JSON(http.StatusInternalServerError, out)
+		// サーバー内の処理でエラーが発生した場合は 500 エラー（DB に接続できない場合は 503 エラー）を返却します。
+		return c.JSON(errorStatus(err), out)
 	}
 
 	// SQL 実行結果から作成されたレコードの ID を取得します。
@@ -181,8 +222,8 @@ func ArticleDelete(c echo.Context) error {
 		// サーバーのログにエラー内容を出力します。
 		c.Logger().Error(err.Error())
 
-		// サーバーサイドでエラーが発生した場合は 500 エラーを返却します。
-		return c.JSON(http.StatusInternalServerError, "")
+		// サーバーサイドでエラーが発生した場合は 500 エラー（DB に接続できない場合は 503 エラー）を返却します。
+		return c.JSON(errorStatus(err), "")
 	}
 
 	// 成功時はステータスコード 200 を返却します。
@@ -197,19 +238,23 @@ func ArticleList(c echo.Context) error {
 
 	// リポジトリの処理を呼び出して記事の一覧データを取得します。
 	// 引数にカーソルの値を渡して、ID のどの位置から 10 件取得するかを指定しています。
-	articles, err := repository.ArticleListByCursor(cursor)
+	articles, hasNext, err := repository.ArticleListByCursorWithNext(cursor)
 
 	// エラーが発生した場合
 	if err != nil {
 		// サーバーのログにエラー内容を出力します。
 		c.Logger().Error(err.Error())
 
-		// クライアントにステータスコード 500 でレスポンスを返します。
+		// クライアントにステータスコード 500（DB に接続できない場合は 503）でレスポンスを返します。
 		// HTML ではなく JSON 形式でデータのみを返却するため、
 		// c.HTMLBlob() ではなく c.JSON() を呼び出しています。
-		return c.JSON(http.StatusInternalServerError, "")
+		return c.JSON(errorStatus(err), "")
 	}
 
+	// 次のページがあるかをレスポンスヘッダーで返却します。
+	// レスポンスの本文は記事の配列のまま変えないため、ヘッダーで返却しています。
+	c.Response().Header().Set("X-Has-Next", strconv.FormatBool(hasNext))
+
 	// エラーがない場合は、ステータスコード 200 でレスポンスを返します。
 	// JSON 形式で返却するため、c.HTMLBlob() ではなく c.JSON() を呼び出しています。
 	return c.JSON(http.StatusOK, articles)
@@ -252,9 +297,9 @@ func ArticleUpdate(c echo.Context) error {
 	}
 
 	// 入力値のチェック（バリデーションチェック）を行います。
-	if err := c.Validate(&article); err != nil {
+	if err := article.Validate(); err != nil {
 		// エラー内容をレスポンスのフィールドに格納します。
-		out.ValidationErrors = article.ValidationErrors(err)
+		out.ValidationErrors = validationMessages(err)
 
 		// 解釈できたパラメータが不正な値の場合は 422 エラーを返却します。
 		return c.JSON(http.StatusUnprocessableEntity, out)
@@ -267,14 +312,19 @@ func ArticleUpdate(c echo.Context) error {
 	article.ID = articleID
 
 	// 記事を更新する処理を呼び出します。
-	_, err := repository.ArticleUpdate(&article)
+	_, err := repository.ArticleUpdate(&article, false)
 
 	if err != nil {
 		// レスポンスの構造体にエラー内容をセットします。
 		out.Message = err.Error()
 
-		// リクエスト自体は正しいにも関わらずサーバー側で処理が失敗した場合は 500 エラーを返却します。
-		return c.JSON(http.StatusInternalServerError, out)
+		// 記事が存在しない、またはゴミ箱に入っている場合は 404 エラーを返却します。
+		if errors.Is(err, repository.ErrArticleNotFound) || errors.Is(err, repository.ErrArticleDeleted) {
+			return c.JSON(http.StatusNotFound, out)
+		}
+
+		// リクエスト自体は正しいにも関わらずサーバー側で処理が失敗した場合は 500 エラー（DB に接続できない場合は 503 エラー）を返却します。
+		return c.JSON(errorStatus(err), out)
 	}
 
 	// レスポンスの構造体に記事データをセットします。
@@ -283,3 +333,21 @@ func ArticleUpdate(c echo.Context) error {
 	// 処理成功時はステータスコード 200 でレスポンスを返却します。
 	return c.JSON(http.StatusOK, out)
 }
+
+// errorStatus はリポジトリから返却されたエラーに応じたステータスコードを返却します。
+// DB に接続できない一時的な障害の場合は 503、それ以外は 500 とします。
+func errorStatus(err error) int {
+	if errors.Is(err, repository.ErrUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// validationMessages はバリデーションエラーからクライアントに返却するメッセージを取り出します。
+func validationMessages(err error) []string {
+	var verr *model.ValidationError
+	if errors.As(err, &verr) {
+		return verr.Messages
+	}
+	return []string{err.Error()}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"go-tech-blog/handler"
 	"go-tech-blog/repository"
@@ -22,7 +27,7 @@ var e = createMux()
 
 func main() {
 	db = connectDB()
-	repository.SetDB(db)
+	repository.Init(db)
 
 	// ルーティングのグループを作成します。
 	auth := e.Group("")
@@ -43,6 +48,9 @@ func main() {
 	e.GET("/articles/:articleID", handler.ArticleShow)         // 詳細画面
 	auth.GET("/articles/:articleID/edit", handler.ArticleEdit) // 編集画面
 
+	// 下書きのプレビューはトークンを知っていれば認証なしで閲覧できるようにします。
+	e.GET("/preview/:token", handler.ArticlePreview) // プレビュー画面
+
 	// HTML ではなく JSON を返却する処理は "/api" で開始するようにします。
 	// 記事に関する処理なので "/articles" を続けます。
 	e.GET("/api/articles", handler.ArticleList)                    // 一覧
@@ -52,9 +60,33 @@ func main() {
 
 	e.GET("/test", handler.Test)
 
-	e.Logger.Fatal(e.Start(":8080"))
+	// サーバーを起動し、終了のシグナルを受け取るまで待ちます。
+	go func() {
+		if err := e.Start(":8080"); err != nil && err != http.ErrServerClosed {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	// 処理中のリクエストが終わるのを待ってからサーバーを停止します。
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		e.Logger.Error(err)
+	}
+
+	// 待ちきれずに処理中のリクエストは、DB を閉じた後に ErrShuttingDown で失敗します。
+	if err := repository.Close(); err != nil {
+		e.Logger.Error(err)
+	}
 }
 
+// shutdownTimeout はサーバーの停止時に処理中のリクエストが終わるのを待つ時間です。
+const shutdownTimeout = 10 * time.Second
+
 func createMux() *echo.Echo {
 	e := echo.New()
 
@@ -0,0 +1,8 @@
+package model
+
+// ArchiveBucket ...
+type ArchiveBucket struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Count int `json:"count"`
+}
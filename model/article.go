@@ -1,22 +1,185 @@
 package model
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"gopkg.in/go-playground/validator.v9"
 )
 
+// 記事のステータスです。
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusPublished = "published"
+)
+
+// IsValidArticleStatus ...
+func IsValidArticleStatus(status string) bool {
+	return status == ArticleStatusDraft || status == ArticleStatusPublished
+}
+
+// validate は構造体タグで指定したルールでバリデーションを行います。
+var validate = validator.New()
+
 // Article ...
 type Article struct {
-	ID         int       `db:"id" form:"id" json:"id"`
-	Title      string    `db:"title" form:"title" validate:"required,max=50" json:"title"`
-	Body       string    `db:"body" form:"body" validate:"required" json:"body"`
-	Created    time.Time `db:"created" json:"created"`
-	Updated    time.Time `db:"updated" json:"updated"`
-	WriterID   int       `db:"writer_id"`
-	WriterName string    `db:"writer_name"`
-	Writer     *Writer   `db:"writer"`
-	Tags       []*Tag    `db:"-"`
+	ID               int        `db:"id" form:"id" json:"id"`
+	Title            string     `db:"title" form:"title" validate:"required,max=50" json:"title"`
+	Body             string     `db:"body" form:"body" validate:"required" json:"body"`
+	BodyFormat       string     `db:"body_format" form:"body_format" validate:"omitempty,oneof=markdown html" json:"body_format"`
+	Status           string     `db:"status" form:"status" validate:"omitempty,oneof=draft published" json:"status"`
+	Slug             string     `db:"slug" form:"slug" validate:"max=255" json:"slug"`
+	NoIndex          bool       `db:"noindex" form:"noindex" json:"noindex"`
+	Views            int        `db:"views" json:"views"`
+	WordCount        int        `db:"word_count" json:"word_count"`
+	ContentHash      string     `db:"content_hash" json:"-"`
+	Featured         bool       `db:"featured" json:"featured"`
+	FeaturedOrder    int        `db:"featured_order" json:"featured_order"`
+	Lang             string     `db:"lang" form:"lang" validate:"max=10" json:"lang"`
+	FeaturedImageURL string     `db:"featured_image_url" form:"featured_image_url" validate:"omitempty,url,max=2048" json:"featured_image_url"`
+	Created          time.Time  `db:"created" json:"created"`
+	Updated          time.Time  `db:"updated" json:"updated"`
+	DeletedAt        *time.Time `db:"deleted_at" json:"-"`
+	PublishAt        *time.Time `db:"publish_at" json:"publish_at"`
+	PublishedAt      *time.Time `db:"published_at" json:"published_at"`
+	WriterID         int        `db:"writer_id"`
+	WriterName       string     `db:"writer_name"`
+	Writer           *Writer    `db:"writer"`
+	PreviewToken     string     `db:"preview_token" json:"-"`
+	TagsCache        string     `db:"tags_cache" json:"-"`
+	Tags             []*Tag     `db:"-" json:"tags"`
+}
+
+// MarshalJSON ...
+func (a Article) MarshalJSON() ([]byte, error) {
+	// 公開 API で返却する形に詰め替えます。
+	// 削除日時などの内部で管理している項目や筆者の個人情報は含めません。
+	tags := a.TagNames()
+
+	writerName := a.WriterName
+	if a.Writer != nil {
+		writerName = a.Writer.Name
+	}
+
+	return json.Marshal(struct {
+		ID          int       `json:"id"`
+		Title       string    `json:"title"`
+		Slug        string    `json:"slug"`
+		Body        string    `json:"body"`
+		Tags        []string  `json:"tags"`
+		WriterName  string    `json:"writer_name"`
+		Created     time.Time `json:"created"`
+		PublishedAt time.Time `json:"published_at"`
+	}{
+		ID:          a.ID,
+		Title:       a.Title,
+		Slug:        a.Slug,
+		Body:        a.Body,
+		Tags:        tags,
+		WriterName:  writerName,
+		Created:     a.Created,
+		PublishedAt: a.PublishedDate(),
+	})
+}
+
+// ValidationError ...
+type ValidationError struct {
+	Messages []string
+}
+
+// Error ...
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Messages, "\n")
+}
+
+// Validate ...
+func (a *Article) Validate() error {
+	// 最初のエラーで止めずに、すべてのルールをまとめてチェックします。
+	err := validate.Struct(a)
+	if err == nil {
+		return nil
+	}
+
+	// 構造体以外が渡された場合などはそのままエラーを返却します。
+	if _, ok := err.(validator.ValidationErrors); !ok {
+		return err
+	}
+
+	// エラー内容をメッセージの一覧にまとめて返却します。
+	return &ValidationError{Messages: a.ValidationErrors(err)}
+}
+
+// ValidateFeaturedImageURL ...
+func ValidateFeaturedImageURL(imageURL string) error {
+	// Article の FeaturedImageURL と同じルールでチェックします。
+	if err := validate.Var(imageURL, "omitempty,url,max=2048"); err != nil {
+		return &ValidationError{Messages: []string{"アイキャッチ画像の URL が不正です。"}}
+	}
+	return nil
+}
+
+// CachedTags ...
+func (a *Article) CachedTags() []string {
+	// キャッシュが空の場合はタグなしとして空のスライスを返却します。
+	if a.TagsCache == "" {
+		return []string{}
+	}
+	return strings.Split(a.TagsCache, ",")
+}
+
+// TagNames ...
+func (a *Article) TagNames() []string {
+	names := make([]string, 0, len(a.Tags))
+	for _, tag := range a.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// HasTag ...
+func (a *Article) HasTag(name string) bool {
+	for _, tag := range a.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasImage ...
+func (a *Article) HasImage() bool {
+	return a.FeaturedImageURL != ""
+}
+
+// IsNew ...
+func (a *Article) IsNew(within time.Duration) bool {
+	// 保存前の記事など、作成日時が設定されていない場合は新着として扱いません。
+	if a.Created.IsZero() {
+		return false
+	}
+	return time.Since(a.Created) <= within
+}
+
+// PublishedDate ...
+func (a *Article) PublishedDate() time.Time {
+	// 作成日時は下書きを最初に保存した日時のため、公開日時として表示する場合はこちらを利用します。
+	// 公開日時を記録する前に公開された記事など、公開日時がない場合は作成日時を返却します。
+	if a.PublishedAt != nil {
+		return *a.PublishedAt
+	}
+	return a.Created
+}
+
+// CreatedIn ...
+func (a *Article) CreatedIn(loc *time.Location) time.Time {
+	// 日時は UTC で保存しているため、表示する際に指定したタイムゾーンに変換します。
+	return a.Created.In(loc)
+}
+
+// UpdatedIn ...
+func (a *Article) UpdatedIn(loc *time.Location) time.Time {
+	return a.Updated.In(loc)
 }
 
 // ValidationErrors ...
@@ -41,6 +204,16 @@ func (a *Article) ValidationErrors(err error) []string {
 			}
 		case "Body":
 			message = "本文は必須です。"
+		case "FeaturedImageURL":
+			message = "アイキャッチ画像の URL が不正です。"
+		case "Lang":
+			message = "言語は最大10文字です。"
+		case "Slug":
+			message = "スラッグは最大255文字です。"
+		case "BodyFormat":
+			message = "本文の形式が不正です。"
+		case "Status":
+			message = "ステータスが不正です。"
 		}
 
 		// メッセージをスライスに追加します。
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ArticleCard ...
+type ArticleCard struct {
+	ID              int       `db:"id" json:"id"`
+	Title           string    `db:"title" json:"title"`
+	Excerpt         string    `db:"excerpt" json:"excerpt"`
+	Created         time.Time `db:"created" json:"created"`
+	PublishedAt     time.Time `db:"published_at" json:"published_at"`
+	Slug            string    `db:"slug" json:"slug"`
+	WriterName      string    `db:"writer_name" json:"writer_name"`
+	WriterAvatarURL string    `db:"writer_avatar_url" json:"writer_avatar_url"`
+	LikeCount       int       `db:"like_count" json:"like_count"`
+	CommentCount    int       `db:"comment_count" json:"comment_count"`
+	Tags            []*Tag    `db:"-" json:"tags"`
+	PrimaryTag      Tag       `db:"primary_tag" json:"primary_tag"`
+}
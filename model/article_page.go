@@ -0,0 +1,8 @@
+package model
+
+// ArticlePage ...
+type ArticlePage struct {
+	Articles   []*Article `json:"articles"`
+	NextCursor int        `json:"next_cursor"`
+	End        bool       `json:"end"`
+}
@@ -0,0 +1,7 @@
+package model
+
+// ArticleRef ...
+type ArticleRef struct {
+	ID    int    `db:"id" json:"id"`
+	Title string `db:"title" json:"title"`
+}
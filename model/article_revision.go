@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ArticleRevision ...
+type ArticleRevision struct {
+	ID        int       `db:"id" json:"id"`
+	ArticleID int       `db:"article_id" json:"article_id"`
+	Revision  int       `db:"revision" json:"revision"`
+	Title     string    `db:"title" json:"title"`
+	Body      string    `db:"body" json:"body"`
+	Created   time.Time `db:"created" json:"created"`
+}
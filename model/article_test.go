@@ -0,0 +1,89 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArticleMarshalJSON(t *testing.T) {
+	created := time.Date(2021, 12, 1, 9, 0, 0, 0, time.UTC)
+	published := time.Date(2021, 12, 2, 9, 0, 0, 0, time.UTC)
+	deleted := time.Date(2021, 12, 3, 9, 0, 0, 0, time.UTC)
+
+	article := Article{
+		ID:           1,
+		Title:        "title",
+		Slug:         "slug",
+		Body:         "body",
+		Tags:         []*Tag{{ID: 1, Name: "go"}, {ID: 2, Name: "mysql"}},
+		WriterName:   "stale",
+		Writer:       &Writer{Name: "writer", Email: "writer@example.com"},
+		PreviewToken: "secret",
+		Created:      created,
+		PublishedAt:  &published,
+		DeletedAt:    &deleted,
+	}
+
+	b, err := json.Marshal(article)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"id":           float64(1),
+		"title":        "title",
+		"slug":         "slug",
+		"body":         "body",
+		"tags":         []interface{}{"go", "mysql"},
+		"writer_name":  "writer",
+		"created":      "2021-12-01T09:00:00Z",
+		"published_at": "2021-12-02T09:00:00Z",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json = %s, want %v", b, want)
+	}
+}
+
+func TestArticleMarshalJSONWithoutPublishedAt(t *testing.T) {
+	// 公開日時がない場合は作成日時を公開日時とし、タグがない場合は空の配列にします。
+	created := time.Date(2021, 12, 1, 9, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(&Article{ID: 1, Created: created, WriterName: "writer"})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	s := string(b)
+	for _, want := range []string{`"published_at":"2021-12-01T09:00:00Z"`, `"tags":[]`, `"writer_name":"writer"`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("json = %s, want to contain %s", s, want)
+		}
+	}
+}
+
+func TestArticleValidate(t *testing.T) {
+	valid := Article{Title: "title", Body: "body"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	invalid := Article{Title: strings.Repeat("a", 51), Status: "unknown"}
+	err := invalid.Validate()
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	// 最初のエラーで止めずに、すべてのルールのエラーが返却されることを確認します。
+	if len(verr.Messages) != 3 {
+		t.Errorf("Validate() messages = %q, want 3 messages", verr.Messages)
+	}
+}
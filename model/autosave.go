@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Autosave ...
+type Autosave struct {
+	WriterID  int       `db:"writer_id" json:"writer_id"`
+	ArticleID int       `db:"article_id" json:"article_id"`
+	Title     string    `db:"title" json:"title"`
+	Body      string    `db:"body" json:"body"`
+	Updated   time.Time `db:"updated" json:"updated"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+)
+
+// 本文の記述形式です。
+const (
+	BodyFormatMarkdown = "markdown"
+	BodyFormatHTML     = "html"
+)
+
+// bodyPolicy は本文の HTML から危険なタグや属性を取り除くポリシーです。
+var bodyPolicy = bluemonday.UGCPolicy()
+
+// BodyHTML ...
+func (a *Article) BodyHTML() template.HTML {
+	// HTML で記述された本文は、危険なタグを取り除いてそのまま表示します。
+	if a.BodyFormat == BodyFormatHTML {
+		return template.HTML(bodyPolicy.Sanitize(a.Body))
+	}
+
+	// それ以外（未設定の既存データを含む）は Markdown として HTML に変換します。
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(a.Body), &buf); err != nil {
+		return template.HTML(template.HTMLEscapeString(a.Body))
+	}
+	return template.HTML(bodyPolicy.SanitizeBytes(buf.Bytes()))
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Comment ...
+type Comment struct {
+	ID        int       `db:"id" json:"id"`
+	ArticleID int       `db:"article_id" json:"article_id"`
+	Body      string    `db:"body" json:"body"`
+	Created   time.Time `db:"created" json:"created"`
+}
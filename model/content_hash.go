@@ -0,0 +1,24 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ContentHash ...
+func ContentHash(title, body string) string {
+	// 改行コードや前後の空白の違いだけの記事は同じ内容とみなすため、正規化してからハッシュ値を求めます。
+	normalize := func(s string) string {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+		return strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+
+	// タイトルと本文の境界がずれても同じ値にならないよう、改行では現れない NUL 文字で区切ります。
+	sum := sha256.Sum256([]byte(normalize(title) + "\x00" + normalize(body)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,37 @@
+package model
+
+// 本文の抜粋の文字数です。
+// 表示する場所ごとに長さを指定し、0 を指定した場合はデフォルトの長さ、
+// ExcerptLengthFull を指定した場合は本文全体を抜粋とします。
+const (
+	ExcerptLengthDefault = 200
+	ExcerptLengthSearch  = 100
+	ExcerptLengthFull    = -1
+)
+
+// ExcerptLength は指定された抜粋の文字数を実際に切り詰める文字数に変換します。
+// 本文全体を抜粋とする場合は -1 を返却します。
+func ExcerptLength(length int) int {
+	switch {
+	case length == 0:
+		return ExcerptLengthDefault
+	case length < 0:
+		return ExcerptLengthFull
+	default:
+		return length
+	}
+}
+
+// Excerpt ...
+func (a *Article) Excerpt(length int) (string, int) {
+	length = ExcerptLength(length)
+
+	// 文字数はバイト数ではなく文字（rune）単位で数えます。
+	// 続きを読む際に抜粋の続きの位置へ移動できるよう、切り詰めた位置を本文の文字単位の位置で返却します。
+	// 切り詰めなかった場合は本文の文字数を返却します。
+	text := []rune(a.Body)
+	if length < 0 || len(text) <= length {
+		return a.Body, len(text)
+	}
+	return string(text[:length]), length
+}
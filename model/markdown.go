@@ -0,0 +1,48 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// paragraphMaxLength は段落の区切りがない本文から抜粋する場合の最大文字数です。
+const paragraphMaxLength = 200
+
+// Markdown の記法を取り除くための正規表現です。
+var (
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdLinePrefix = regexp.MustCompile(`(?m)^\s*(#{1,6}\s+|>\s?|[-*+]\s+|\d+\.\s+)`)
+	mdEmphasis   = regexp.MustCompile("(\\*\\*|__|\\*|_|~~|`)")
+	mdCodeFence  = regexp.MustCompile("(?m)^```.*$")
+	mdBlankLine  = regexp.MustCompile(`\n[ \t]*\n`)
+)
+
+// FirstParagraph ...
+func (a *Article) FirstParagraph() string {
+	// 改行コードを LF に揃えます。
+	body := strings.ReplaceAll(a.Body, "\r\n", "\n")
+	body = strings.TrimSpace(body)
+
+	// 空行（Markdown の段落の区切り）までを最初の段落とします。
+	if loc := mdBlankLine.FindStringIndex(body); loc != nil {
+		return stripMarkdown(body[:loc[0]])
+	}
+
+	// 空行がない場合は本文全体を一定の文字数で切り詰めます。
+	text := []rune(stripMarkdown(body))
+	if len(text) > paragraphMaxLength {
+		return string(text[:paragraphMaxLength])
+	}
+	return string(text)
+}
+
+// stripMarkdown は Markdown の記法を取り除いたテキストを返却します。
+func stripMarkdown(s string) string {
+	s = mdCodeFence.ReplaceAllString(s, "")
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdLinePrefix.ReplaceAllString(s, "")
+	s = mdEmphasis.ReplaceAllString(s, "")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,9 @@
+package model
+
+// RepoStats ...
+type RepoStats struct {
+	ArticlesByStatus map[string]int `db:"-" json:"articles_by_status"`
+	Writers          int            `db:"writers" json:"writers"`
+	Tags             int            `db:"tags" json:"tags"`
+	Comments         int            `db:"comments" json:"comments"`
+}
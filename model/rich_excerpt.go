@@ -0,0 +1,65 @@
+package model
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark"
+)
+
+// RichExcerpt ...
+func (a *Article) RichExcerpt(maxChars int) template.HTML {
+	maxChars = ExcerptLength(maxChars)
+	if maxChars < 0 {
+		return a.BodyHTML()
+	}
+
+	// HTML で記述された本文は見出しなどを判別せず、タグを取り除いたテキストを切り詰めます。
+	if a.BodyFormat == BodyFormatHTML {
+		text := truncateRunes(strings.TrimSpace(textPolicy.Sanitize(a.Body)), maxChars)
+		return template.HTML("<p>" + template.HTMLEscapeString(text) + "</p>")
+	}
+
+	// 空行で区切られたブロックを先頭から順に、表示される文字数が maxChars に収まる間だけ採用します。
+	// 見出しや太字などの記法はブロックごとそのまま残すため、記法の途中で切れることはありません。
+	body := strings.TrimSpace(strings.ReplaceAll(a.Body, "\r\n", "\n"))
+	var lead []string
+	var rest string
+	remaining := maxChars
+	for _, block := range mdBlankLine.Split(body, -1) {
+		// コードブロックは抜粋に向かないため、そこで打ち切ります。
+		if mdCodeFence.MatchString(block) {
+			break
+		}
+
+		text := stripMarkdown(block)
+		n := len([]rune(text))
+		if n > remaining {
+			// 収まらないブロックは記法を取り除いたテキストを残りの文字数で切り詰めます。
+			rest = truncateRunes(text, remaining)
+			break
+		}
+		lead = append(lead, block)
+		remaining -= n
+	}
+
+	// 採用したブロックを Markdown として HTML に変換し、危険なタグを取り除きます。
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(strings.Join(lead, "\n\n")), &buf); err != nil {
+		return template.HTML(template.HTMLEscapeString(a.FirstParagraph()))
+	}
+	if rest != "" {
+		buf.WriteString("<p>" + template.HTMLEscapeString(rest) + "</p>")
+	}
+	return template.HTML(bodyPolicy.SanitizeBytes(buf.Bytes()))
+}
+
+// truncateRunes は文字列を文字（rune）単位で length 文字に切り詰め、切り詰めた場合は末尾に「…」を付けます。
+func truncateRunes(s string, length int) string {
+	text := []rune(s)
+	if len(text) <= length {
+		return s
+	}
+	return string(text[:length]) + "…"
+}
@@ -0,0 +1,49 @@
+package model
+
+import "encoding/json"
+
+// SearchCursor ...
+type SearchCursor struct {
+	Rank int `json:"rank"`
+	ID   int `json:"id"`
+}
+
+// SearchResult ...
+type SearchResult struct {
+	*Article
+	Rank int `db:"search_rank" json:"rank"`
+}
+
+// MarshalJSON ...
+func (r SearchResult) MarshalJSON() ([]byte, error) {
+	// Article を埋め込んでいるため、そのままでは Article の MarshalJSON が使われて順位が含まれません。
+	// 記事を公開 API の形に変換したうえで、順位を追加します。
+	fields := map[string]json.RawMessage{}
+	if r.Article != nil {
+		b, err := json.Marshal(r.Article)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	rank, err := json.Marshal(r.Rank)
+	if err != nil {
+		return nil, err
+	}
+	fields["rank"] = rank
+	return json.Marshal(fields)
+}
+
+// Cursor ...
+func (r *SearchResult) Cursor() SearchCursor {
+	return SearchCursor{Rank: r.Rank, ID: r.ID}
+}
+
+// PopularSearch ...
+type PopularSearch struct {
+	Keyword string `db:"keyword" json:"keyword"`
+	Count   int    `db:"count" json:"count"`
+}
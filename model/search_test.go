@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSearchResultMarshalJSON(t *testing.T) {
+	result := &SearchResult{
+		Article: &Article{ID: 1, Title: "title", Slug: "slug"},
+		Rank:    2,
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if got["rank"] != float64(2) {
+		t.Errorf("rank = %v, want 2 (json: %s)", got["rank"], b)
+	}
+	if got["title"] != "title" {
+		t.Errorf("title = %v, want title (json: %s)", got["title"], b)
+	}
+	if _, ok := got["deleted_at"]; ok {
+		t.Errorf("deleted_at should not be included (json: %s)", b)
+	}
+}
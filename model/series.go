@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Series ...
+type Series struct {
+	ID       int        `db:"id" json:"id"`
+	Title    string     `db:"title" json:"title"`
+	Created  time.Time  `db:"created" json:"created"`
+	Articles []*Article `db:"-" json:"articles"`
+}
+
+// SeriesNeighbors ...
+type SeriesNeighbors struct {
+	SeriesID int      `json:"series_id"`
+	Position int      `json:"position"`
+	Prev     *Article `json:"prev"`
+	Next     *Article `json:"next"`
+}
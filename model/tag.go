@@ -2,6 +2,13 @@ package model
 
 // Tag ...
 type Tag struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+	ID     int    `db:"id" json:"id"`
+	Name   string `db:"name" json:"name"`
+	Hidden bool   `db:"hidden" json:"hidden"`
+}
+
+// TagWithCount ...
+type TagWithCount struct {
+	Tag
+	Count int `db:"count" json:"count"`
 }
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// textPolicy は本文の単語数を数えるため、HTML からすべてのタグを取り除くポリシーです。
+var textPolicy = bluemonday.StrictPolicy()
+
+// CountWords ...
+func (a *Article) CountWords() int {
+	// 記法やタグを取り除いたテキストで数えます。
+	text := stripMarkdown(a.Body)
+	if a.BodyFormat == BodyFormatHTML {
+		text = textPolicy.Sanitize(a.Body)
+	}
+
+	// 空白で区切られた語を一語として数えます。
+	// 日本語などの空白で区切らない文字は、一文字を一語として数えます。
+	count := 0
+	for _, field := range strings.Fields(text) {
+		inWord := false
+		for _, r := range field {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+				count++
+				inWord = false
+				continue
+			}
+			if !inWord {
+				count++
+				inWord = true
+			}
+		}
+	}
+	return count
+}
@@ -1,8 +1,31 @@
 package model
 
+import "time"
+
 // Writer ...
 type Writer struct {
-	ID       int        `db:"id"`
-	Name     string     `db:"name"`
-	Articles []*Article `db:"-"`
+	ID         int        `db:"id"`
+	Name       string     `db:"name"`
+	Slug       string     `db:"slug"`
+	Email      string     `db:"email"`
+	Timezone   string     `db:"timezone"`
+	AvatarURL  string     `db:"avatar_url"`
+	TotalViews int        `db:"total_views"`
+	Articles   []*Article `db:"-"`
+
+	// DefaultTags は筆者が作成する記事に自動で付けるタグ名です。
+	DefaultTags []string `db:"-"`
+}
+
+// Location ...
+func (w *Writer) Location() *time.Location {
+	// タイムゾーンが設定されていない、または不正な場合は UTC とします。
+	if w.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
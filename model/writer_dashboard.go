@@ -0,0 +1,9 @@
+package model
+
+// WriterDashboard ...
+type WriterDashboard struct {
+	PostCount    int `db:"post_count" json:"post_count"`
+	TotalViews   int `db:"total_views" json:"total_views"`
+	TotalLikes   int `db:"total_likes" json:"total_likes"`
+	CommentCount int `db:"comment_count" json:"comment_count"`
+}
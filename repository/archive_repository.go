@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+)
+
+// 年別のアーカイブで指定できる年の範囲です。
+const (
+	archiveMinYear = 2000
+	archiveMaxYear = 2100
+)
+
+// ErrInvalidYear ...
+var ErrInvalidYear = errors.New("invalid archive year")
+
+// ArticleListOnThisDay ...
+func ArticleListOnThisDay(now time.Time) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListOnThisDay", time.Now())
+
+	month, day := int(now.Month()), now.Day()
+
+	// うるう年以外の 2 月 28 日には、2 月 29 日に公開された記事も対象にします。
+	// こうすることで 2 月 29 日の記事がうるう年にしか表示されない問題を避けます。
+	includeLeapDay := month == 2 && day == 28 && !isLeapYear(now.Year())
+
+	// 過去の年の同じ月日に公開された記事を、新しい年の順に取得します。
+	// 公開中の一覧と同じく、検索エンジンから除外した記事や非公開のタグが付いている記事は含めません。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE ` + publicArticleFilter + `
+	AND YEAR(` + articlePublishedDate + `) < ?
+	AND MONTH(` + articlePublishedDate + `) = ?
+	AND (DAY(` + articlePublishedDate + `) = ? OR (? AND DAY(` + articlePublishedDate + `) = 29))
+	ORDER BY ` + articlePublishedDate + ` desc, id desc;`)
+
+	articles := make([]*model.Article, 0)
+	if err := getDB().Select(&articles, query, now.Year(), month, day, includeLeapDay); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListOnThisDay: %w", err))
+	}
+
+	return articles, nil
+}
+
+// isLeapYear はうるう年かどうかを判定します。
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// ArticleArchiveCounts ...
+func ArticleArchiveCounts() ([]model.ArchiveBucket, error) {
+	defer logSlowQuery("ArticleArchiveCounts", time.Now())
+
+	// 公開中の記事を公開した年月ごとに集計し、新しい年月の順に取得します。
+	// 一覧の件数と一致するよう、公開中の一覧と同じ記事のみを集計します。
+	query := buildQuery(`SELECT
+		DATE_FORMAT(` + articlePublishedDate + `, '%Y-%m') AS ym,
+		COUNT(*) AS count
+	FROM articles
+	WHERE ` + publicArticleFilter + `
+	AND ` + articlePublishedDate + ` IS NOT NULL
+	GROUP BY ym
+	ORDER BY ym desc;`)
+
+	var rows []struct {
+		YearMonth string `db:"ym"`
+		Count     int    `db:"count"`
+	}
+	if err := getDB().Select(&rows, query); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleArchiveCounts: %w", err))
+	}
+
+	// "2006-01" 形式の年月を年と月に分けて格納します。
+	buckets := make([]model.ArchiveBucket, 0, len(rows))
+	for _, row := range rows {
+		t, err := time.Parse("2006-01", row.YearMonth)
+		if err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleArchiveCounts: %w", err))
+		}
+		buckets = append(buckets, model.ArchiveBucket{
+			Year:  t.Year(),
+			Month: int(t.Month()),
+			Count: row.Count,
+		})
+	}
+
+	return buckets, nil
+}
+
+// ArticleListByYear ...
+func ArticleListByYear(year, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByYear", time.Now())
+
+	// 範囲外の年が指定された場合は、存在しないページとしてエラーを返却します。
+	if year < archiveMinYear || year > archiveMaxYear {
+		return nil, ErrInvalidYear
+	}
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 年月ごとの件数（ArticleArchiveCounts）と同じく、公開中の一覧と同じ記事を公開日時で絞り込みます。
+	// 指定した年の始まりから翌年の始まりまでの範囲で比較します。
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where(publicArticleFilter).
+		Where(articlePublishedDate+" >= ? AND "+articlePublishedDate+" < ?", from, to).
+		OrderBy("id desc").
+		Limit(10).
+		Build()
+
+	// 記事がない年の場合は空のスライスを返却します。
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByYear: %w", err))
+	}
+
+	return limitArticles("ArticleListByYear", articles, articlePageSize), nil
+}
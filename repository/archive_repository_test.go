@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"go-tech-blog/model"
+	"testing"
+)
+
+func TestArchiveExcludesNonPublicArticles(t *testing.T) {
+	NewTestDB(t)
+
+	create := func(title string) *model.Article {
+		t.Helper()
+		article := &model.Article{Title: title, Body: "body"}
+		if _, err := ArticleCreate(article); err != nil {
+			t.Fatalf("ArticleCreate(%q): %v", title, err)
+		}
+		return article
+	}
+
+	public := create("public")
+	noindex := create("noindex")
+	hidden := create("hidden")
+
+	if err := ArticleSetNoIndex(noindex.ID, true); err != nil {
+		t.Fatalf("ArticleSetNoIndex: %v", err)
+	}
+	tag, err := TagCreate("secret")
+	if err != nil {
+		t.Fatalf("TagCreate: %v", err)
+	}
+	if err := TagSetHidden(tag.ID, true); err != nil {
+		t.Fatalf("TagSetHidden: %v", err)
+	}
+	if err := ArticleSetTags(hidden.ID, []int{tag.ID}); err != nil {
+		t.Fatalf("ArticleSetTags: %v", err)
+	}
+
+	// 検索エンジンから除外した記事と非公開のタグが付いている記事は、年別のアーカイブに含まれません。
+	articles, err := ArticleListByYear(public.Created.UTC().Year(), 0)
+	if err != nil {
+		t.Fatalf("ArticleListByYear: %v", err)
+	}
+	if len(articles) != 1 || articles[0].ID != public.ID {
+		t.Errorf("ArticleListByYear() returned %d articles, want only the public article", len(articles))
+	}
+
+	buckets, err := ArticleArchiveCounts()
+	if err != nil {
+		t.Fatalf("ArticleArchiveCounts: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].Count != 1 {
+		t.Errorf("ArticleArchiveCounts() = %+v, want one bucket with one article", buckets)
+	}
+
+	// 一年後の同じ日として、今日公開した記事を取得します。
+	onThisDay, err := ArticleListOnThisDay(public.Created.UTC().AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("ArticleListOnThisDay: %v", err)
+	}
+	if len(onThisDay) != 1 || onThisDay[0].ID != public.ID {
+		t.Errorf("ArticleListOnThisDay() returned %d articles, want only the public article", len(onThisDay))
+	}
+}
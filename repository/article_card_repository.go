@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"math"
+	"time"
+)
+
+// cardExcerptLength は LEFT 関数に渡す、一覧のカードに表示する本文の抜粋の文字数です。
+// 本文全体を抜粋とする場合は int 型の最大値を渡して、切り詰めないようにします。
+func cardExcerptLength(length int) int {
+	if length = model.ExcerptLength(length); length < 0 {
+		return math.MaxInt32
+	}
+	return length
+}
+
+// ArticleListCards ...
+func ArticleListCards(cursor, excerptLength int) ([]*model.ArticleCard, error) {
+	defer logSlowQuery("ArticleListCards", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 一覧のカードに必要なカラムのみを取得します。
+	// 本文は全体を取得せず、LEFT 関数で先頭の数文字のみを取得します。
+	// 抜粋の文字数は表示する場所ごとに引数で指定し、0 の場合はデフォルトの文字数とします。
+	// 筆者が設定されていない記事も取得できるように LEFT JOIN にしています。
+	// カードに筆者のアバター画像を表示するため、筆者名と合わせて URL も取得します。
+	// 下書きやゴミ箱に入っている記事の抜粋が表示されないよう、公開中の一覧と同じ記事のみを取得します。
+	query := buildQuery(`SELECT
+		articles.id AS id,
+		articles.title AS title,
+		LEFT(articles.body, ?) AS excerpt,
+		articles.created AS created,
+		` + articlePublishedDate + ` AS published_at,
+		articles.slug AS slug,
+		COALESCE(writers.name, '') AS writer_name,
+		COALESCE(writers.avatar_url, '') AS writer_avatar_url
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	WHERE articles.id < ?
+	AND ` + publicArticleFilter + `
+	ORDER BY articles.id desc
+	LIMIT 10`)
+
+	cards := make([]*model.ArticleCard, 0, 10)
+	if err := getDB().Select(&cards, query, cardExcerptLength(excerptLength), maxID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListCards: %w", err))
+	}
+
+	if err := attachCardTags(cards); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListCards: %w", err))
+	}
+
+	return cards, nil
+}
+
+// ArticleListCardsWithStats ...
+func ArticleListCardsWithStats(cursor, excerptLength int) ([]*model.ArticleCard, error) {
+	defer logSlowQuery("ArticleListCardsWithStats", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// ArticleListCards() と同じカラムに加えて、いいね数とコメント数を一度のクエリで取得します。
+	// 記事ごとの件数は集計したサブクエリを LEFT JOIN し、一件もない記事は COALESCE で 0 にします。
+	// 対象の記事は ArticleListCards() と同じく、公開中の一覧と同じ記事のみです。
+	query := buildQuery(`SELECT
+		articles.id AS id,
+		articles.title AS title,
+		LEFT(articles.body, ?) AS excerpt,
+		articles.created AS created,
+		` + articlePublishedDate + ` AS published_at,
+		articles.slug AS slug,
+		COALESCE(writers.name, '') AS writer_name,
+		COALESCE(writers.avatar_url, '') AS writer_avatar_url,
+		COALESCE(l.count, 0) AS like_count,
+		COALESCE(c.count, 0) AS comment_count
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	LEFT JOIN (
+		SELECT article_id, COUNT(*) AS count FROM article_likes GROUP BY article_id
+	) AS l ON l.article_id = articles.id
+	LEFT JOIN (
+		SELECT article_id, COUNT(*) AS count FROM comments GROUP BY article_id
+	) AS c ON c.article_id = articles.id
+	WHERE articles.id < ?
+	AND ` + publicArticleFilter + `
+	ORDER BY articles.id desc
+	LIMIT 10`)
+
+	cards := make([]*model.ArticleCard, 0, 10)
+	if err := getDB().Select(&cards, query, cardExcerptLength(excerptLength), maxID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListCardsWithStats: %w", err))
+	}
+
+	if err := attachCardTags(cards); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListCardsWithStats: %w", err))
+	}
+
+	return cards, nil
+}
+
+// ArticleListCardsPrimaryTag ...
+func ArticleListCardsPrimaryTag(cursor, excerptLength int) ([]*model.ArticleCard, error) {
+	defer logSlowQuery("ArticleListCardsPrimaryTag", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// ArticleListCards() と同じカラムに加えて、代表のタグを一件だけ結合して取得します。
+	// 代表のタグは記事に付いているタグの中で ID が最小のタグとし、すべてのタグは取得しません。
+	// タグのない記事は LEFT JOIN の結果が NULL になるため、COALESCE で空のタグにします。
+	// 対象の記事は ArticleListCards() と同じく、公開中の一覧と同じ記事のみです。
+	query := buildQuery(`SELECT
+		articles.id AS id,
+		articles.title AS title,
+		LEFT(articles.body, ?) AS excerpt,
+		articles.created AS created,
+		` + articlePublishedDate + ` AS published_at,
+		articles.slug AS slug,
+		COALESCE(writers.name, '') AS writer_name,
+		COALESCE(writers.avatar_url, '') AS writer_avatar_url,
+		COALESCE(pt.id, 0) AS 'primary_tag.id',
+		COALESCE(pt.name, '') AS 'primary_tag.name'
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	LEFT JOIN tags AS pt ON pt.id = (
+		SELECT MIN(at.tag_id) FROM articles_tags AS at WHERE at.article_id = articles.id
+	)
+	WHERE articles.id < ?
+	AND ` + publicArticleFilter + `
+	ORDER BY articles.id desc
+	LIMIT 10`)
+
+	cards := make([]*model.ArticleCard, 0, 10)
+	if err := getDB().Select(&cards, query, cardExcerptLength(excerptLength), maxID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListCardsPrimaryTag: %w", err))
+	}
+
+	return cards, nil
+}
+
+// attachCardTags はカードのタグ情報を一回のクエリでまとめて取得して格納します。
+func attachCardTags(cards []*model.ArticleCard) error {
+	articleIDs := make([]int, len(cards))
+	for i, card := range cards {
+		articleIDs[i] = card.ID
+	}
+
+	tagListMap, err := TagListMapByArticleIDs(articleIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		card.Tags = tagListMap[card.ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"go-tech-blog/model"
+	"sync"
+	"time"
+)
+
+// ArticleLoaderWait は ArticleLoader が ID をまとめるために待つ時間です。
+var ArticleLoaderWait = 2 * time.Millisecond
+
+// articleLoaderMaxBatch は一度にまとめて取得する記事の最大件数です。
+// これを超えた場合は待たずにその時点までの ID で取得します。
+const articleLoaderMaxBatch = 100
+
+// ArticleLoader ...
+type ArticleLoader struct {
+	mu    sync.Mutex
+	cache map[int]*articleLoaderResult
+	batch *articleLoaderBatch
+}
+
+// articleLoaderResult は一件の記事の取得結果です。
+// done が閉じられるまで article と err を参照してはいけません。
+type articleLoaderResult struct {
+	done    chan struct{}
+	article *model.Article
+	err     error
+}
+
+// articleLoaderBatch はまとめて取得するのを待っている記事の ID と取得結果です。
+type articleLoaderBatch struct {
+	ids     []int
+	results []*articleLoaderResult
+	once    sync.Once
+}
+
+// NewArticleLoader ...
+func NewArticleLoader() *ArticleLoader {
+	return &ArticleLoader{cache: make(map[int]*articleLoaderResult)}
+}
+
+// Load ...
+func (l *ArticleLoader) Load(id int) (*model.Article, error) {
+	l.mu.Lock()
+
+	// 同じローダーで取得済み、または取得中の記事はその結果を返却します。
+	if r, ok := l.cache[id]; ok {
+		l.mu.Unlock()
+		<-r.done
+		return r.article, r.err
+	}
+
+	r := &articleLoaderResult{done: make(chan struct{})}
+	l.cache[id] = r
+
+	// 待っている ID がない場合は、一定時間後にまとめて取得するバッチを作成します。
+	if l.batch == nil {
+		b := &articleLoaderBatch{}
+		l.batch = b
+		time.AfterFunc(ArticleLoaderWait, func() { l.dispatch(b) })
+	}
+	b := l.batch
+	b.ids = append(b.ids, id)
+	b.results = append(b.results, r)
+
+	// 最大件数に達した場合は待たずに取得します。
+	full := len(b.ids) >= articleLoaderMaxBatch
+	if full {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	if full {
+		go l.dispatch(b)
+	}
+
+	<-r.done
+	return r.article, r.err
+}
+
+// dispatch はバッチの ID の記事をまとめて取得し、それぞれの取得結果を設定します。
+// 件数が最大に達した場合と待ち時間が過ぎた場合の両方から呼ばれるため、一度だけ実行します。
+func (l *ArticleLoader) dispatch(b *articleLoaderBatch) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.batch == b {
+			l.batch = nil
+		}
+		l.mu.Unlock()
+
+		articles, err := ArticleListByIDs(b.ids)
+
+		byID := make(map[int]*model.Article, len(articles))
+		for _, article := range articles {
+			byID[article.ID] = article
+		}
+
+		// 存在しない、またはゴミ箱に入っている記事は ErrArticleNotFound とします。
+		for i, r := range b.results {
+			switch article, ok := byID[b.ids[i]]; {
+			case err != nil:
+				r.err = err
+			case !ok:
+				r.err = ErrArticleNotFound
+			default:
+				r.article = article
+			}
+			close(r.done)
+		}
+	})
+}
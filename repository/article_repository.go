@@ -1,80 +1,608 @@
 package repository
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"go-tech-blog/model"
-	"math"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultLang は言語の指定がない記事を作成する際に設定する言語です。
+var DefaultLang = "ja"
+
+// articleColumnNames は記事データを取得する際に SELECT 句に指定するカラムです。
+// SELECT * ではテーブルと構造体のカラムがずれた場合に取得に失敗するため、
+// model.Article の db タグに合わせて明示的に指定します。
+// writer_id は NULL になりうるため含めず、articleColumnList() で COALESCE 関数を通して追加します。
+var articleColumnNames = []string{
+	"id", "title", "body", "body_format", "status", "slug", "lang",
+	"featured_image_url", "noindex", "featured", "featured_order", "views", "word_count", "content_hash", "tags_cache",
+	"created", "updated", "deleted_at", "publish_at", "published_at",
+}
+
+// articleColumnList は articleColumnNames を SELECT 句に指定する形に連結して返却します。
+// table を指定した場合は、他のテーブルと結合しても曖昧にならないよう各カラムにテーブル名を付けます。
+// 筆者が設定されていない記事は writer_id が NULL になるため、COALESCE 関数で 0 にします。
+func articleColumnList(table string) string {
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+
+	cols := make([]string, 0, len(articleColumnNames)+1)
+	for _, name := range articleColumnNames {
+		cols = append(cols, prefix+name)
+	}
+	cols = append(cols, "COALESCE("+prefix+"writer_id, 0) AS writer_id")
+	return strings.Join(cols, ", ")
+}
+
+// articleColumns は記事データを取得する際に SELECT 句に指定するカラムです。
+// articleColumnsQualified は他のテーブルと結合する場合に指定する、テーブル名を付けたカラムです。
+var (
+	articleColumns          = articleColumnList("")
+	articleColumnsQualified = articleColumnList("articles")
 )
 
+// articlePublishedDate は記事の公開日時を求める式です。
+// 公開日時がない記事（公開日時を記録する前に作成された記事など）は作成日時を公開日時とします。
+// 公開中の記事を日付で並べ替えたり絞り込んだりする場合は、created ではなくこの式を利用します。
+const articlePublishedDate = "COALESCE(articles.published_at, articles.created)"
+
+// publicArticleFilter は公開中の一覧や検索結果に表示する記事に絞り込む条件です。
+// 公開中でゴミ箱に入っておらず、noindex や非公開のタグが設定されていない記事を対象にします。
+// 名前付きパラメータのクエリでも利用できるよう、ステータスは bind せずに埋め込みます。
+const publicArticleFilter = `articles.status = '` + model.ArticleStatusPublished + `'
+	AND articles.deleted_at IS NULL
+	AND articles.noindex = 0
+	AND ` + hiddenTagFilter
+
+// articleColumnsWithoutBody は articleColumns のうち本文を空文字に置き換えたカラムの一覧です。
+// 一覧のカードなど、本文を表示しない場合に転送量を減らすために利用します。
+var articleColumnsWithoutBody = strings.Replace(articleColumns, " body,", " '' AS body,", 1)
+
+// ErrArticleNotFound ...
+var ErrArticleNotFound = errors.New("article not found")
+
+// ErrArticleDeleted ...
+var ErrArticleDeleted = errors.New("article is in the trash")
+
+// ErrInvalidStatus ...
+var ErrInvalidStatus = errors.New("invalid article status")
+
+// ErrConcurrentModification ...
+var ErrConcurrentModification = errors.New("article was modified concurrently")
+
+// ErrInvalidDateRange ...
+var ErrInvalidDateRange = errors.New("invalid date range")
+
+// ErrSlugRequired ...
+var ErrSlugRequired = errors.New("slug is required")
+
 // ArticleCreate ...
 func ArticleCreate(article *model.Article) (sql.Result, error) {
+	defer logSlowQuery("ArticleCreate", time.Now())
+
+	// 未指定の項目に初期値を設定し、保存する前に記事データの内容をチェックします。
+	if err := prepareArticleCreate(article); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreate: %w", err))
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreate: %w", err))
+	}
+
+	// 構造体を引数に渡して INSERT 文を実行します。
+	res, err := insertArticle(tx, article)
+	if err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+
+		// エラー内容を返却します。
+		return nil, ClassifyError(fmt.Errorf("ArticleCreate: %w", err))
+	}
+
+	// SQL の実行に成功した場合はコミットします。
+	tx.Commit()
+
+	// SQL の実行結果を返却します。
+	return res, nil
+}
+
+// ArticleCreateReturning ...
+func ArticleCreateReturning(article *model.Article) (*model.Article, error) {
+	defer logSlowQuery("ArticleCreateReturning", time.Now())
+
+	// 未指定の項目に初期値を設定し、保存する前に記事データの内容をチェックします。
+	if err := prepareArticleCreate(article); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateReturning: %w", err))
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateReturning: %w", err))
+	}
+
+	if _, err := insertArticle(tx, article); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateReturning: %w", err))
+	}
+
+	// 同じトランザクション内で作成した記事データを取得し直し、
+	// DB のデフォルト値やアプリケーションで設定した値を含めて返却します。
+	var created model.Article
+	query := buildQuery(`SELECT ` + articleColumns + `, preview_token FROM articles WHERE id = ?;`)
+	if err := tx.Get(&created, query, article.ID); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateReturning: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateReturning: %w", err))
+	}
+
+	return &created, nil
+}
+
+// defaultWriterID は筆者が指定されていない記事を作成する際に設定する筆者の ID です。
+// 0 の場合は筆者を設定せず、writer_id を NULL として保存します。
+var (
+	defaultWriterID   int
+	defaultWriterIDMu sync.RWMutex
+)
+
+// SetDefaultWriterID ...
+func SetDefaultWriterID(id int) {
+	defaultWriterIDMu.Lock()
+	defer defaultWriterIDMu.Unlock()
+
+	defaultWriterID = id
+}
+
+// getDefaultWriterID は SetDefaultWriterID() で設定した筆者の ID を返却します。
+func getDefaultWriterID() int {
+	defaultWriterIDMu.RLock()
+	defer defaultWriterIDMu.RUnlock()
+
+	return defaultWriterID
+}
+
+// prepareArticleCreate は作成する記事の未指定の項目に初期値を設定し、内容をチェックします。
+func prepareArticleCreate(article *model.Article) error {
+	// ステータスの指定がない場合は公開状態で作成します。
+	if article.Status == "" {
+		article.Status = model.ArticleStatusPublished
+	}
+
+	// 言語の指定がない場合はデフォルトの言語で作成します。
+	if article.Lang == "" {
+		article.Lang = DefaultLang
+	}
+
+	// 筆者の指定がない場合は、インポートした記事なども一覧の JOIN で扱えるよう設定された筆者で作成します。
+	if article.WriterID == 0 {
+		article.WriterID = getDefaultWriterID()
+	}
+
+	// 本文の形式の指定がない場合は Markdown として作成します。
+	if article.BodyFormat == "" {
+		article.BodyFormat = model.BodyFormatMarkdown
+	}
+
+	// HTML 形式の本文は危険なタグを取り除いてから保存します。
+	sanitizeBody(article)
+
+	// 保存する前に記事データの内容をチェックします。
+	return article.Validate()
+}
+
+// setDerivedColumns は本文から求めて保存するカラム（単語数とハッシュ値）を構造体に設定します。
+// タイトルや本文を保存する処理では、必ず保存する前に呼び出します。
+func setDerivedColumns(article *model.Article) {
+	article.WordCount = article.CountWords()
+	article.ContentHash = model.ContentHash(article.Title, article.Body)
+}
+
+// insertArticle は記事データを作成し、作成日時・更新日時・ID を構造体に設定します。
+func insertArticle(tx *sqlx.Tx, article *model.Article) (sql.Result, error) {
 	// 現在日時を取得します
-	now := time.Now()
+	now := timeNow()
 
 	// 構造体に現在日時を設定します。
 	article.Created = now
 	article.Updated = now
 
-	// クエリ文字列を生成します。
-	query := `INSERT INTO articles (title, body, created, updated)
-	VALUES (:title, :body, :created, :updated);`
+	// 公開状態で作成する場合は、作成日時を公開日時とします。
+	if article.Status == model.ArticleStatusPublished && article.PublishedAt == nil {
+		article.PublishedAt = &now
+	}
 
-	// トランザクションを開始します。
-	tx := db.MustBegin()
+	// 本文から求める単語数とハッシュ値を設定します。
+	setDerivedColumns(article)
+
+	// 下書きを共有するためのプレビュー用のトークンを生成します。
+	if article.PreviewToken == "" {
+		token, err := newPreviewToken()
+		if err != nil {
+			return nil, err
+		}
+		article.PreviewToken = token
+	}
+
+	// クエリ文字列を生成します。
+	// 筆者が指定されていない（0 の）場合は、NULLIF 関数で NULL として保存します。
+	query := buildQuery(`INSERT INTO articles (title, body, body_format, status, slug, lang, featured_image_url, noindex, preview_token, word_count, content_hash, writer_id, created, updated, published_at)
+	VALUES (:title, :body, :body_format, :status, :slug, :lang, :featured_image_url, :noindex, :preview_token, :word_count, :content_hash, NULLIF(:writer_id, 0), :created, :updated, :published_at);`)
 
 	// クエリ文字列と構造体を引数に渡して SQL を実行します。
 	// クエリ文字列内の「:title」「:body」「:created」「:updated」は構造体の値で置換されます。
 	// 構造体タグで指定してあるフィールドが対象となります。（`db:"title"` など）
 	res, err := tx.NamedExec(query, article)
 	if err != nil {
-		// エラーが発生した場合はロールバックします。
-		tx.Rollback()
-
-		// エラー内容を返却します。
 		return nil, err
 	}
 
-	// SQL の実行に成功した場合はコミットします。
-	tx.Commit()
+	// 作成されたレコードの ID を構造体にセットします。
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	article.ID = int(id)
 
-	// SQL の実行結果を返却します。
 	return res, nil
 }
 
+// newPreviewToken は推測されないランダムなプレビュー用のトークンを生成します。
+func newPreviewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // ArticleListByCursor ...
 func ArticleListByCursor(cursor int) ([]*model.Article, error) {
-	// 引数で渡されたカーソルの値が 0 以下の場合は、代わりに int 型の最大値で置き換えます。
-	if cursor <= 0 {
-		cursor = math.MaxInt32
+	return ArticleListByCursorContext(context.Background(), cursor)
+}
+
+// ArticleListByCursorContext ...
+func ArticleListByCursorContext(ctx context.Context, cursor int) ([]*model.Article, error) {
+	// 公開中の一覧のため、公開中の記事のみを取得します。
+	return ArticleListContext(ctx, ListOptions{Cursor: int64(cursor), IncludeBody: true, Status: model.ArticleStatusPublished})
+}
+
+// ArticleListByCursorWithNext ...
+func ArticleListByCursorWithNext(cursor int) ([]*model.Article, bool, error) {
+	// 次のページがあるかを判定するため、一ページの件数より一件多く取得します。
+	// 一件多く取得できた場合は次のページがあるとし、多く取得した記事は返却しません。
+	// 最後のページがちょうど 10 件の場合も、次のページがないことを判定できます。
+	articles, err := ArticleListContext(context.Background(), ListOptions{
+		Cursor:      int64(cursor),
+		IncludeBody: true,
+		Limit:       articlePageSize + 1,
+		Status:      model.ArticleStatusPublished,
+	})
+	if err != nil {
+		return nil, false, err
 	}
 
-	// ID の降順に記事データを 10 件取得するクエリ文字列を生成します。
-	query := `SELECT *
-	FROM articles
-	WHERE id < ?
-	ORDER BY id desc
-	LIMIT 10`
+	if len(articles) > articlePageSize {
+		return articles[:articlePageSize], true, nil
+	}
+	return articles, false, nil
+}
+
+// articleListMaxLimit は ArticleList() で一度に取得できる記事の最大件数です。
+const articleListMaxLimit = 100
+
+// ListOptions ...
+type ListOptions struct {
+	// Cursor は前のページの最後の記事の ID です。0 以下の場合は先頭のページを取得します。
+	Cursor int64
+	// IncludeBody が false の場合は本文を取得せず、空文字にします。
+	IncludeBody bool
+	// Limit は取得する件数です。0 以下の場合は 10 件、最大で 100 件です。
+	Limit int
+	// Status は取得する記事のステータスです。空の場合はステータスで絞り込みません。
+	Status string
+}
+
+// ArticleList ...
+func ArticleList(opts ListOptions) ([]*model.Article, error) {
+	return ArticleListContext(context.Background(), opts)
+}
+
+// ArticleListContext ...
+func ArticleListContext(ctx context.Context, opts ListOptions) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleList", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(opts.Cursor)
+
+	// 件数の指定がない場合は 10 件、多すぎる場合は最大件数に切り詰めます。
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = articlePageSize
+	}
+	if limit > articleListMaxLimit {
+		limit = articleListMaxLimit
+	}
+
+	// 本文が不要な場合は、本文を取得しないカラムの一覧を利用します。
+	columns := articleColumnsWithoutBody
+	if opts.IncludeBody {
+		columns = articleColumns
+	}
+
+	// ID の降順に記事データを取得するクエリ文字列を生成します。
+	// ID は重複しないため、ページをまたいでも記事が抜けたり重複したりすることはありません。
+	// created など重複しうるカラムで並べ替える場合は、必ず id を第二キーにしてカーソルにも含めてください。
+	// ゴミ箱に入っている記事は一覧に表示しません。
+	// noindex が設定された記事は単独のページとして公開するもので、一覧には表示しません。
+	b := newSelectBuilder(columns, "articles").
+		Where("id < ?", maxID).
+		Where("deleted_at IS NULL").
+		Where("noindex = 0").
+		Where(hiddenTagFilter)
+
+	// ステータスが指定された場合は、そのステータスの記事のみに絞り込みます。
+	if opts.Status != "" {
+		if !model.IsValidArticleStatus(opts.Status) {
+			return nil, ErrInvalidStatus
+		}
+		b = b.Where("status = ?", opts.Status)
+	}
+
+	query, args := b.OrderBy("id desc").Limit(limit).Build()
 
 	// クエリ結果を格納するスライスを初期化します。
-	// 10 件取得すると決まっているため、サイズとキャパシティを指定しています。
-	articles := make([]*model.Article, 0, 10)
+	// 取得する件数が決まっているため、キャパシティを指定しています。
+	articles := make([]*model.Article, 0, limit)
 
 	// クエリ結果を格納する変数、クエリ文字列、パラメータを指定してクエリを実行します。
-	if err := db.Select(&articles, query, cursor); err != nil {
-		return nil, err
+	// コンテキストがキャンセルされた場合は、クエリを中断して context.Canceled を返却します。
+	// 期限が設定されていない場合は SetQueryTimeout() で設定した期限を超えると中断します。
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	if err := getDB().SelectContext(ctx, &articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleList: %w", err))
+	}
+
+	return limitArticles("ArticleList", articles, limit), nil
+}
+
+// ArticleSearchByCursor ...
+func ArticleSearchByCursor(keyword string, cursor int) ([]*model.Article, error) {
+	// キーワードが空の場合は、呼び出し元で処理を分けずに済むよう通常の一覧を返却します。
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return ArticleListByCursor(cursor)
+	}
+
+	defer logSlowQuery("ArticleSearchByCursor", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// ArticleListByCursor() と同じく公開中の記事のうち、タイトルか本文がキーワードを含む記事を ID の降順に取得します。
+	// キーワード中の % や _ はワイルドカードとして扱わないよう、likePattern() でエスケープします。
+	pattern := likePattern(keyword)
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where(publicArticleFilter).
+		Where("(title LIKE ? OR body LIKE ?)", pattern, pattern).
+		OrderBy("id desc").
+		Limit(articlePageSize).
+		Build()
+
+	articles := make([]*model.Article, 0, articlePageSize)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleSearchByCursor: %w", err))
+	}
+
+	return limitArticles("ArticleSearchByCursor", articles, articlePageSize), nil
+}
+
+// ArticleListExcludingWriters ...
+func ArticleListExcludingWriters(blockedIDs []int, cursor int) ([]*model.Article, error) {
+	// ミュートしている筆者がいない場合は通常の一覧を返却します。
+	if len(blockedIDs) == 0 {
+		return ArticleListByCursor(cursor)
+	}
+
+	defer logSlowQuery("ArticleListExcludingWriters", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// ArticleListByCursor() と同じ条件に加えて、指定した筆者の記事を除外します。
+	// writer_id が NULL の場合は NOT IN の結果が NULL になり除外されてしまうため、筆者のいない記事は明示的に含めます。
+	q1, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where("(writer_id IS NULL OR writer_id NOT IN(?))", blockedIDs).
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("deleted_at IS NULL").
+		Where("noindex = 0").
+		Where(hiddenTagFilter).
+		OrderBy("id desc").
+		Limit(10).
+		Build()
+
+	// IN 句を利用するクエリを作成するには sqlx パッケージの In() 関数を利用します。
+	q2, args, err := sqlx.In(q1, args...)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListExcludingWriters: %w", err))
+	}
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, q2, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListExcludingWriters: %w", err))
+	}
+
+	return limitArticles("ArticleListExcludingWriters", articles, articlePageSize), nil
+}
+
+// ArticleListFeedForWriter ...
+func ArticleListFeedForWriter(viewerWriterID, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListFeedForWriter", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 公開中の記事に加えて、閲覧している筆者自身の記事は下書きも含めて一つのクエリで取得します。
+	// 筆者のいない記事の writer_id は NULL で保存しているため、ログインしていない（0 の）場合は公開中の記事のみになります。
+	// 自身の記事は非公開のタグや noindex が設定されていても表示します。
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where("deleted_at IS NULL").
+		Where("((status = ? AND noindex = 0 AND "+hiddenTagFilter+") OR writer_id = ?)", model.ArticleStatusPublished, viewerWriterID).
+		OrderBy("id desc").
+		Limit(10).
+		Build()
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListFeedForWriter: %w", err))
+	}
+
+	return limitArticles("ArticleListFeedForWriter", articles, articlePageSize), nil
+}
+
+// MaxUnseenIDs は ArticleListUnseen() で IN 句に含める既読の記事 ID の最大件数です。
+// これを超える場合は IN 句が大きくなりすぎないよう、通常の一覧を取得してから既読の記事を取り除きます。
+var MaxUnseenIDs = 500
+
+// ArticleListUnseen ...
+func ArticleListUnseen(seenIDs []int, cursor int) ([]*model.Article, error) {
+	// 既読の記事がない場合は通常の一覧を返却します。
+	if len(seenIDs) == 0 {
+		return ArticleListByCursor(cursor)
+	}
+
+	// 既読の記事が多すぎる場合は、一覧を取得してから取り除きます。
+	if len(seenIDs) > MaxUnseenIDs {
+		articles, err := articleListUnseenFiltered(seenIDs, cursor)
+		if err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleListUnseen: %w", err))
+		}
+		return articles, nil
+	}
+
+	defer logSlowQuery("ArticleListUnseen", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// ArticleListByCursor() と同じ条件に加えて、既読の記事を除外します。
+	q1, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where("id NOT IN(?)", seenIDs).
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("deleted_at IS NULL").
+		Where("noindex = 0").
+		Where(hiddenTagFilter).
+		OrderBy("id desc").
+		Limit(10).
+		Build()
+
+	// IN 句を利用するクエリを作成するには sqlx パッケージの In() 関数を利用します。
+	q2, args, err := sqlx.In(q1, args...)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListUnseen: %w", err))
+	}
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, q2, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListUnseen: %w", err))
+	}
+
+	return limitArticles("ArticleListUnseen", articles, articlePageSize), nil
+}
+
+// articleListUnseenFiltered は通常の一覧を順に取得し、既読の記事を取り除いて 1 ページ分の記事を返却します。
+func articleListUnseenFiltered(seenIDs []int, cursor int) ([]*model.Article, error) {
+	seen := make(map[int]bool, len(seenIDs))
+	for _, id := range seenIDs {
+		seen[id] = true
+	}
+
+	articles := make([]*model.Article, 0, articlePageSize)
+	for len(articles) < articlePageSize {
+		page, err := ArticleListByCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, article := range page {
+			if !seen[article.ID] && len(articles) < articlePageSize {
+				articles = append(articles, article)
+			}
+		}
+
+		// 最後のページまで取得した場合は終了します。
+		if len(page) < articlePageSize {
+			break
+		}
+		cursor = page[len(page)-1].ID
 	}
 
 	return articles, nil
 }
 
+// articlePageSize は記事の一覧を一度に取得する件数です。
+const articlePageSize = 10
+
+// ArticleListPageByCursor ...
+func ArticleListPageByCursor(cursor int) (*model.ArticlePage, error) {
+	articles, err := ArticleListByCursor(cursor)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListPageByCursor: %w", err))
+	}
+
+	// 次に取得するカーソルとして、取得できた記事の中で最小の ID を設定します。
+	// ID の降順に並んでいるため、最後の記事の ID が最小になります。
+	page := &model.ArticlePage{
+		Articles: articles,
+		End:      len(articles) < articlePageSize,
+	}
+	if len(articles) != 0 {
+		page.NextCursor = articles[len(articles)-1].ID
+	}
+
+	return page, nil
+}
+
 // ArticleDelete ...
 func ArticleDelete(id int) error {
+	defer logSlowQuery("ArticleDelete", time.Now())
+
 	// 記事データを削除するクエリ文字列を生成します。
-	query := "DELETE FROM articles WHERE id = ?"
+	query := buildQuery(`DELETE FROM articles WHERE id = ?;`)
 
 	// トランザクションを開始します。
-	tx := db.MustBegin()
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleDelete: %w", err))
+	}
+
+	// タグの紐付けやコメントなど、記事を参照しているデータを先に削除します。
+	// 途中で失敗した場合に参照先のない行が残らないよう、記事の削除と同じトランザクションで行います。
+	if err := deleteArticleDependents(tx, []int{id}); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleDelete: %w", err))
+	}
 
 	// クエリ文字列とパラメータを指定して SQL を実行します。
 	if _, err := tx.Exec(query, id); err != nil {
@@ -82,19 +610,31 @@ func ArticleDelete(id int) error {
 		tx.Rollback()
 
 		// エラー内容を返却します。
-		return err
+		return ClassifyError(fmt.Errorf("ArticleDelete: %w", err))
 	}
 
 	// エラーがない場合はコミットします。
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleDelete: %w", err))
+	}
+	return nil
 }
 
 // ArticleGetByID ...
 func ArticleGetByID(id int) (*model.Article, error) {
+	return ArticleGetByIDContext(context.Background(), id)
+}
+
+// ArticleGetByIDContext ...
+func ArticleGetByIDContext(ctx context.Context, id int) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetByID", time.Now())
+
 	// クエリ文字列を生成します。
-	query := `SELECT *
+	// 公開中の詳細画面で利用するため、下書きやゴミ箱に入っている記事は取得しません。
+	// 下書きは ArticleGetByPreviewToken() でプレビュー用のトークンを指定した場合のみ取得できます。
+	query := buildQuery(`SELECT ` + articleColumns + `
 	FROM articles
-	WHERE id = ?;`
+	WHERE id = ? AND status = ? AND deleted_at IS NULL;`)
 
 	// クエリ結果を格納する変数を宣言します。
 	// 複数件取得の場合はスライスでしたが、一件取得の場合は構造体になります。
@@ -102,34 +642,110 @@ func ArticleGetByID(id int) (*model.Article, error) {
 
 	// 結果を格納する構造体、クエリ文字列、パラメータを指定して SQL を実行します。
 	// 複数件の取得の場合は db.Select() でしたが、一件取得の場合は db.Get() になります。
-	if err := db.Get(&article, query, id); err != nil {
+	// 期限が設定されていない場合は SetQueryTimeout() で設定した期限を超えると中断します。
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	if err := getDB().GetContext(ctx, &article, query, id, model.ArticleStatusPublished); err != nil {
+		// 記事が存在しない場合や公開中でない場合は ErrArticleNotFound を返却します。
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+
 		// エラーが発生した場合はエラーを返却します。
-		return nil, err
+		return nil, ClassifyError(fmt.Errorf("ArticleGetByIDContext: %w", err))
 	}
 
 	// エラーがない場合は記事データを返却します。
 	return &article, nil
 }
 
+// ArticleGetForEdit ...
+func ArticleGetForEdit(id int) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetForEdit", time.Now())
+
+	// 認証済みの編集画面で利用するため、下書きもステータスに関わらず取得します。
+	// ゴミ箱に入っている記事は、元に戻すまで編集できません。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE id = ? AND deleted_at IS NULL;`)
+
+	var article model.Article
+	if err := getDB().Get(&article, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleGetForEdit: %w", err))
+	}
+
+	return &article, nil
+}
+
+// ArticleGetByIDIncludingDeleted ...
+func ArticleGetByIDIncludingDeleted(id int) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetByIDIncludingDeleted", time.Now())
+
+	// 管理画面のゴミ箱から閲覧・復元できるように、ゴミ箱に入っている記事も取得します。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE id = ?;`)
+
+	var article model.Article
+	if err := getDB().Get(&article, query, id); err != nil {
+		// 記事が存在しない場合は ErrArticleNotFound を返却します。
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleGetByIDIncludingDeleted: %w", err))
+	}
+
+	return &article, nil
+}
+
 // ArticleUpdate ...
-func ArticleUpdate(article *model.Article) (sql.Result, error) {
+func ArticleUpdate(article *model.Article, regenerateSlug bool) (sql.Result, error) {
+	defer logSlowQuery("ArticleUpdate", time.Now())
+
+	// 保存する前に記事データの内容をチェックします。
+	if err := article.Validate(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdate: %w", err))
+	}
+
+	// HTML 形式の本文は危険なタグを取り除いてから保存します。
+	if err := sanitizeBodyForUpdate(article); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdate: %w", err))
+	}
+
+	// 本文から求める単語数とハッシュ値を設定します。
+	setDerivedColumns(article)
+
 	// 現在日時を取得します
-	now := time.Now()
+	now := timeNow()
 
 	// 構造体に現在日時を設定します。
 	article.Updated = now
 
 	// クエリ文字列を生成します。
-	query := `UPDATE articles
+	// 更新するのは本文などの内容のみで、ステータス・おすすめ・スラッグ・アイキャッチ画像は更新しません。
+	// フォームで送信されなかった項目がゼロ値で上書きされないよう、これらの変更は
+	// ArticleSetStatus()・ArticleSetFeatured()・ArticleSetSlug()・ArticleSetFeaturedImage() で行います。
+	// （スラッグは regenerateSlug が指定された場合のみ、タイトルから生成し直します。）
+	query := buildQuery(`UPDATE articles
 	SET title = :title,
 		body = :body,
+		body_format = COALESCE(NULLIF(:body_format, ''), body_format),
+		word_count = :word_count,
+		content_hash = :content_hash,
 		updated = :updated
-	WHERE id = :id;`
+	WHERE id = :id AND deleted_at IS NULL;`)
 
 	// トランザクションを開始します。
-	tx := db.MustBegin()
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdate: %w", err))
+	}
 
 	// クエリ文字列と引数で渡ってきた構造体を指定して、SQL を実行します。
+	// ゴミ箱に入っている記事は更新しません。編集する場合は先に ArticleRestore() で元に戻します。
 	// クエリ文字列内の :title, :body, :id には、
 	// 第 2 引数の Article 構造体の Title, Body, ID が bind されます。
 	// 構造体に db タグで指定した値が紐付けされます。
@@ -140,11 +756,39 @@ func ArticleUpdate(article *model.Article) (sql.Result, error) {
 		tx.Rollback()
 
 		// エラーを返却します。
-		return nil, err
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdate: %w", err))
+	}
+
+	// 更新件数は値が変わらない場合にも 0 件になるため、0 件の場合は記事の状態を確認します。
+	if n, _ := res.RowsAffected(); n == 0 {
+		var deleted bool
+		err := tx.Get(&deleted, buildQuery(`SELECT deleted_at IS NOT NULL FROM articles WHERE id = ?;`), article.ID)
+		if err == sql.ErrNoRows {
+			tx.Rollback()
+			return nil, ErrArticleNotFound
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, ClassifyError(fmt.Errorf("ArticleUpdate: %w", err))
+		}
+		if deleted {
+			tx.Rollback()
+			return nil, ErrArticleDeleted
+		}
+	}
+
+	// 指定された場合は新しいタイトルからスラッグを生成し直します。
+	if regenerateSlug {
+		if err := regenerateArticleSlug(tx, article); err != nil {
+			tx.Rollback()
+			return nil, ClassifyError(fmt.Errorf("ArticleUpdate: %w", err))
+		}
 	}
 
 	// エラーがない場合はコミットします。
-	tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdate: %w", err))
+	}
 
 	// SQL の実行結果を返却します。
 	return res, nil
@@ -152,69 +796,80 @@ func ArticleUpdate(article *model.Article) (sql.Result, error) {
 
 // ArticleGetWithWriterName ...
 func ArticleGetWithWriterName(id int) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetWithWriterName", time.Now())
+
 	// クエリ文字列を生成します。
 	// 取得カラムは AS 句でリネームします。
 	// リネーム後の名称は Article 構造体の db タグで指定した名称とします。
 	// Null の可能性のあるカラムは COALESCE 関数を使って初期値を指定すると Go でのエラーを回避できます。
-	query := `SELECT
+	query := buildQuery(`SELECT
 		articles.id AS id,
 		articles.title AS title,
 		COALESCE(writers.name, '') AS writer_name
 	FROM articles
 	INNER JOIN writers ON writers.id = articles.writer_id
-	WHERE articles.id = ? AND articles.writer_id IS NOT NULL;`
+	WHERE articles.id = ? AND articles.writer_id IS NOT NULL;`)
 
 	var article model.Article
-	if err := db.Get(&article, query, id); err != nil {
-		return nil, err
+	if err := getDB().Get(&article, query, id); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleGetWithWriterName: %w", err))
 	}
 	return &article, nil
 }
 
 // ArticleGetWithWriter ...
 func ArticleGetWithWriter(id int) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetWithWriter", time.Now())
+
 	// 構造体を階層化した状態でデータを取得する場合は、
 	// AS 句でのリネームでドット繋ぎの名称にします。
 	// Article 構造体の db タグで指定した `writer` にドットで続けて、
 	// Writer 構造体の db タグで指定した `id` と `name` を指定します。
-	query := `SELECT
+	// 筆者が設定されていない記事も取得できるように LEFT JOIN にして、
+	// NULL になるカラムは COALESCE 関数で初期値を指定しています。
+	query := buildQuery(`SELECT
 		articles.id AS id,
 		articles.title AS title,
-		writers.id AS 'writer.id',
-		writers.name AS 'writer.name'
+		COALESCE(articles.writer_id, 0) AS writer_id,
+		COALESCE(writers.id, 0) AS 'writer.id',
+		COALESCE(writers.name, '') AS 'writer.name'
 	FROM articles
-	INNER JOIN writers ON writers.id = articles.writer_id
-	WHERE articles.id = ?;`
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	WHERE articles.id = ?;`)
 
 	var article model.Article
-	if err := db.Get(&article, query, id); err != nil {
-		return nil, err
+	if err := getDB().Get(&article, query, id); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleGetWithWriter: %w", err))
 	}
 	return &article, nil
 }
 
 // ArticleListByWriterID ...
 func ArticleListByWriterID(writerID int) ([]*model.Article, error) {
-	query := `SELECT * FROM articles WHERE writer_id = ?;`
+	defer logSlowQuery("ArticleListByWriterID", time.Now())
+
+	query := buildQuery(`SELECT ` + articleColumns + ` FROM articles WHERE writer_id = ?;`)
 	var articles []*model.Article
-	if err := db.Select(&articles, query, writerID); err != nil {
-		return nil, err
+	if err := getDB().Select(&articles, query, writerID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByWriterID: %w", err))
 	}
 	return articles, nil
 }
 
 // ArticleGetWithTags ...
 func ArticleGetWithTags(id int) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetWithTags", time.Now())
+
 	// 記事データを取得します。
 	article, err := ArticleGetByID(id)
 	if err != nil {
-		return nil, err
+		return nil, ClassifyError(fmt.Errorf("ArticleGetWithTags: %w", err))
 	}
 
 	// タグデータを取得します。
 	tags, err := TagListByArticleID(id)
 	if err != nil {
-		return nil, err
+		return nil, ClassifyError(fmt.Errorf("ArticleGetWithTags: %w", err))
 	}
 
 	// 記事の構造体にタグ情報を格納します。
@@ -225,30 +880,1162 @@ func ArticleGetWithTags(id int) (*model.Article, error) {
 
 // ArticleListWithTags ...
 func ArticleListWithTags() ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListWithTags", time.Now())
+
 	// 記事の一覧データを取得します。
-	q1 := `SELECT id, title FROM articles;`
+	q1 := buildQuery(`SELECT id, title FROM articles;`)
 
 	var articles []*model.Article
-	if err := db.Select(&articles, q1); err != nil {
-		return nil, err
+	if err := getDB().Select(&articles, q1); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListWithTags: %w", err))
 	}
 
-	// 取得できた記事データ一覧から記事 ID を抽出します。
-	articleIDs := make([]int, len(articles))
-	for i, article := range articles {
-		articleIDs[i] = article.ID
+	// 記事の一覧データにタグ情報を格納します。
+	if err := attachTags(articles); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListWithTags: %w", err))
 	}
 
-	// タグ情報を map で取得します。
-	tagListMap, err := TagListMapByArticleIDs(articleIDs)
+	return articles, nil
+}
+
+// ArticleListFullWithTags ...
+func ArticleListFullWithTags() ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListFullWithTags", time.Now())
+
+	// 一覧のカードに本文や日時も表示できるように、記事のすべてのカラムを取得します。
+	query := buildQuery(`SELECT ` + articleColumns + ` FROM articles ORDER BY id desc;`)
+
+	var articles []*model.Article
+	if err := getDB().Select(&articles, query); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListFullWithTags: %w", err))
+	}
+
+	// 記事の件数に関わらず、タグ情報は一回のクエリでまとめて取得します。
+	if err := attachTags(articles); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListFullWithTags: %w", err))
+	}
+
+	return articles, nil
+}
+
+// attachTags は記事の一覧データにタグ情報を格納します。
+// タグ情報は TagListMapByArticleIDs() で一回のクエリでまとめて取得します。
+func attachTags(articles []*model.Article) error {
+	// 取得できた記事データ一覧から記事 ID を抽出します。
+	articleIDs := make([]int, len(articles))
+	for i, article := range articles {
+		articleIDs[i] = article.ID
+	}
+
+	// タグ情報を map で取得します。
+	tagListMap, err := TagListMapByArticleIDs(articleIDs)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// 記事の一覧データにタグ情報を格納します。
 	for _, article := range articles {
 		article.Tags = tagListMap[article.ID]
 	}
 
+	return nil
+}
+
+// ArticleListExcludingTag ...
+func ArticleListExcludingTag(tagID, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListExcludingTag", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 指定したタグが付いていない公開中の記事を ID の降順に 10 件取得します。
+	// タグが一つも付いていない記事も NOT EXISTS の条件を満たすため取得対象になります。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE id < ?
+	AND NOT EXISTS (
+		SELECT 1 FROM articles_tags AS at
+		WHERE at.article_id = articles.id AND at.tag_id = ?
+	)
+	AND articles.status = ?
+	AND articles.deleted_at IS NULL
+	AND articles.noindex = 0
+	AND ` + hiddenTagFilter + `
+	ORDER BY id desc
+	LIMIT 10`)
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, maxID, tagID, model.ArticleStatusPublished); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListExcludingTag: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleUpsertBySlug ...
+func ArticleUpsertBySlug(article *model.Article) (*model.Article, error) {
+	defer logSlowQuery("ArticleUpsertBySlug", time.Now())
+
+	// スラッグをキーにするため、空の場合はエラーを返却します。
+	if article.Slug == "" {
+		return nil, ErrSlugRequired
+	}
+
+	// 未指定の項目に初期値を設定し、保存する前に記事データの内容をチェックします。
+	if err := prepareArticleCreate(article); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpsertBySlug: %w", err))
+	}
+
+	// 同じスラッグの記事が同時に作成された場合は、一意制約違反になった側が一度だけやり直します。
+	// やり直す際は他方が作成した記事が見つかるため、その記事を更新します。
+	err := upsertArticleBySlug(article)
+	if isDuplicateSlug(err) {
+		err = upsertArticleBySlug(article)
+	}
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpsertBySlug: %w", err))
+	}
+
+	return article, nil
+}
+
+// upsertArticleBySlug は一つのトランザクションで、スラッグが一致する記事を更新するか、ない場合は作成します。
+func upsertArticleBySlug(article *model.Article) error {
+	now := timeNow()
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return err
+	}
+
+	// 同じスラッグの記事をロックせずに取得します。
+	// 存在しないスラッグを FOR UPDATE で取得するとインデックスのギャップがロックされ、
+	// 同時に同じスラッグで作成しようとしたリクエスト同士がデッドロックするためです。
+	// 同時に作成された場合は、スラッグの一意制約により後から INSERT した側がエラーになります。
+	var existing model.Article
+	q1 := buildQuery(`SELECT id FROM articles WHERE slug = ?;`)
+	err = tx.Get(&existing, q1, article.Slug)
+
+	switch {
+	case err == sql.ErrNoRows:
+		// スラッグが新しい場合は記事を作成します。
+		if _, err := insertArticle(tx, article); err != nil {
+			tx.Rollback()
+			return err
+		}
+	case err != nil:
+		tx.Rollback()
+		return err
+	default:
+		// 見つかった記事を ID で FOR UPDATE でロックしながら取得し直します。
+		// 主キーでの取得のため、ロックされるのはその記事の行のみです。
+		q2 := buildQuery(`SELECT id, created, published_at FROM articles WHERE id = ? FOR UPDATE;`)
+		if err := tx.Get(&existing, q2, existing.ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// スラッグが一致する記事がある場合は ID と作成日時を引き継いで更新します。
+		article.ID = existing.ID
+		article.Created = existing.Created
+		article.Updated = now
+		setDerivedColumns(article)
+
+		// 公開日時は初めて公開した日時のまま変更せず、下書きから公開する場合のみ設定します。
+		article.PublishedAt = existing.PublishedAt
+		if article.Status == model.ArticleStatusPublished && article.PublishedAt == nil {
+			article.PublishedAt = &now
+		}
+
+		q3 := buildQuery(`UPDATE articles
+		SET title = :title,
+			body = :body,
+			body_format = :body_format,
+			status = :status,
+			featured_image_url = :featured_image_url,
+			word_count = :word_count,
+			content_hash = :content_hash,
+			updated = :updated,
+			published_at = :published_at
+		WHERE id = :id;`)
+		if _, err := tx.NamedExec(q3, article); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// articlesSlugIndex は記事のスラッグの一意制約のインデックス名です。
+// スラッグが空の記事は複数あるため、空の場合に NULL となる slug_key カラムに作成しています。
+const articlesSlugIndex = "uq_articles_slug"
+
+// isDuplicateSlug はエラーが記事のスラッグの一意制約違反かどうかを判定します。
+func isDuplicateSlug(err error) bool {
+	return isDuplicateEntry(err, articlesSlugIndex, "articles.slug")
+}
+
+// ArticleListOrphaned ...
+func ArticleListOrphaned() ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListOrphaned", time.Now())
+
+	// 存在しない筆者を参照している記事を取得します。
+	// LEFT JOIN で筆者データが結合できなかった（writers.id が NULL の）記事が対象です。
+	query := buildQuery(`SELECT ` + articleColumnsQualified + `
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	WHERE articles.writer_id IS NOT NULL AND writers.id IS NULL
+	ORDER BY articles.id;`)
+
+	var articles []*model.Article
+	if err := getDB().Select(&articles, query); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListOrphaned: %w", err))
+	}
+	return articles, nil
+}
+
+// ArticleTouch ...
+func ArticleTouch(id int) error {
+	defer logSlowQuery("ArticleTouch", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleTouch: %w", err))
+	}
+
+	// 更新対象の記事が存在するかをロックしながら確認します。
+	// MySQL は値が変わらない場合に更新件数を 0 件と返すため、
+	// 同じ秒の間に続けて呼ばれた場合も区別できるよう件数ではなく存在確認で判定します。
+	var exists int
+	if err := tx.Get(&exists, buildQuery(`SELECT 1 FROM articles WHERE id = ? FOR UPDATE;`), id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrArticleNotFound
+		}
+		return ClassifyError(fmt.Errorf("ArticleTouch: %w", err))
+	}
+
+	// 更新日時のみを現在日時で更新します。作成日時や本文には触れません。
+	if _, err := tx.Exec(buildQuery(`UPDATE articles SET updated = ? WHERE id = ?;`), timeNow(), id); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleTouch: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleTouch: %w", err))
+	}
+	return nil
+}
+
+// ArticleListByIDs ...
+func ArticleListByIDs(ids []int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByIDs", time.Now())
+
+	// ID が一つも指定されていない場合は空の結果を返却します。
+	if len(ids) == 0 {
+		return []*model.Article{}, nil
+	}
+
+	// 指定した ID の記事をまとめて取得します。
+	// ArticleGetByID() と同じく、ゴミ箱に入っている記事は取得しません。
+	// 存在しない ID は結果に含まれず、並び順は指定した順ではなく ID の降順になります。
+	q1 := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE id IN(?) AND deleted_at IS NULL
+	ORDER BY id desc`)
+
+	// IN 句を利用するクエリを作成するには sqlx パッケージの In() 関数を利用します。
+	q2, args, err := sqlx.In(q1, ids)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByIDs: %w", err))
+	}
+
+	articles := make([]*model.Article, 0, len(ids))
+	if err := getDB().Select(&articles, q2, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByIDs: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleListByWriterIDs ...
+func ArticleListByWriterIDs(writerIDs []int, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByWriterIDs", time.Now())
+
+	// クエリ結果を格納するスライスを初期化します。
+	articles := make([]*model.Article, 0, 10)
+
+	// 筆者が一人も指定されていない場合は、すべての記事ではなく空の結果を返却します。
+	if len(writerIDs) == 0 {
+		return articles, nil
+	}
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 指定した筆者の公開中の記事を ID の降順に 10 件取得するクエリ文字列を生成します。
+	// フォローしている筆者のフィードなど読者に表示するため、下書きやゴミ箱に入っている記事は含めません。
+	q1 := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE writer_id IN(?) AND id < ?
+	AND ` + publicArticleFilter + `
+	ORDER BY id desc
+	LIMIT 10`)
+
+	// IN 句を利用するクエリを作成するには sqlx パッケージの In() 関数を利用します。
+	q2, args, err := sqlx.In(q1, writerIDs, maxID)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByWriterIDs: %w", err))
+	}
+
+	if err := getDB().Select(&articles, q2, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByWriterIDs: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleTrash ...
+func ArticleTrash(id int) error {
+	defer logSlowQuery("ArticleTrash", time.Now())
+
+	// 記事データは削除せず、削除日時を設定してゴミ箱に移動します。
+	query := buildQuery(`UPDATE articles SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleTrash: %w", err))
+	}
+
+	res, err := tx.Exec(query, timeNow(), id)
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleTrash: %w", err))
+	}
+
+	// 更新対象がない場合は、記事が存在しないか既にゴミ箱に入っています。
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+		return ErrArticleNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleTrash: %w", err))
+	}
+	return nil
+}
+
+// ArticleRestore ...
+func ArticleRestore(id int) error {
+	defer logSlowQuery("ArticleRestore", time.Now())
+
+	// 削除日時を NULL に戻してゴミ箱から復元します。
+	query := buildQuery(`UPDATE articles SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleRestore: %w", err))
+	}
+
+	res, err := tx.Exec(query, id)
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleRestore: %w", err))
+	}
+
+	// 更新対象がない場合は、記事が存在しないかゴミ箱に入っていません。
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+		return ErrArticleNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleRestore: %w", err))
+	}
+	return nil
+}
+
+// ArticleGetBySlugFull ...
+func ArticleGetBySlugFull(slug string) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetBySlugFull", time.Now())
+
+	// スラッグに一致する公開中の記事を筆者データと合わせて取得します。
+	// 下書きやゴミ箱に入っている記事は公開ページに表示しないため取得しません。
+	// 筆者が設定されていない記事も取得できるように LEFT JOIN にして、
+	// NULL になるカラムは COALESCE 関数で初期値を指定しています。
+	query := buildQuery(`SELECT
+		articles.id AS id,
+		articles.title AS title,
+		articles.body AS body,
+		articles.status AS status,
+		articles.slug AS slug,
+		articles.featured_image_url AS featured_image_url,
+		articles.created AS created,
+		articles.updated AS updated,
+		COALESCE(articles.writer_id, 0) AS writer_id,
+		COALESCE(writers.id, 0) AS 'writer.id',
+		COALESCE(writers.name, '') AS 'writer.name',
+		COALESCE(writers.slug, '') AS 'writer.slug'
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	WHERE articles.slug = ?
+	AND articles.status = ?
+	AND articles.deleted_at IS NULL;`)
+
+	var article model.Article
+	if err := getDB().Get(&article, query, slug, model.ArticleStatusPublished); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleGetBySlugFull: %w", err))
+	}
+
+	// タグデータを取得して記事の構造体に格納します。
+	tags, err := TagListByArticleID(article.ID)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleGetBySlugFull: %w", err))
+	}
+	article.Tags = tags
+
+	return &article, nil
+}
+
+// ArticleListRecent ...
+func ArticleListRecent(within time.Duration, limit int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListRecent", time.Now())
+
+	// 取得件数や期間が 0 以下の場合は空のスライスを返却します。
+	if limit <= 0 || within <= 0 {
+		return []*model.Article{}, nil
+	}
+
+	// 指定した期間内に公開された記事を新しい順に取得します。
+	// 期間内の記事が limit 件に満たない場合も、期間より前の記事で埋めずにそのまま返却します。
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("deleted_at IS NULL").
+		Where("noindex = 0").
+		Where(articlePublishedDate+" >= ?", timeNow().Add(-within)).
+		Where(hiddenTagFilter).
+		OrderBy(articlePublishedDate + " desc, id desc").
+		Limit(limit).
+		Build()
+
+	articles := make([]*model.Article, 0, limit)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListRecent: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleListRandom ...
+func ArticleListRandom(seed int64, limit int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListRandom", time.Now())
+
+	// 取得件数が 0 以下の場合は空のスライスを返却し、多すぎる場合は最大件数に切り詰めます。
+	if limit <= 0 {
+		return []*model.Article{}, nil
+	}
+	if limit > articleListMaxLimit {
+		limit = articleListMaxLimit
+	}
+
+	// シードと ID から求めたハッシュ値の順に並べ替えます。
+	// RAND(seed) は行を読み込む順番によって結果が変わるため、行ごとに決まるハッシュ値を利用します。
+	// 同じシードであれば記事が追加されても既存の記事同士の順番は変わらず、常に同じ順番で取得できます。
+	// シードは整数のため、パラメータとして bind せずにクエリ文字列へ埋め込みます。
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("deleted_at IS NULL").
+		Where("noindex = 0").
+		Where(hiddenTagFilter).
+		OrderBy(fmt.Sprintf("MD5(CONCAT(%d, ':', id)), id", seed)).
+		Limit(limit).
+		Build()
+
+	articles := make([]*model.Article, 0, limit)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListRandom: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleListWithImages ...
+func ArticleListWithImages(cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListWithImages", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// アイキャッチ画像が設定されている公開中の記事のみを、ID の降順で取得します。
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("deleted_at IS NULL").
+		Where("noindex = 0").
+		Where("featured_image_url <> ''").
+		Where(hiddenTagFilter).
+		OrderBy("id desc").
+		Limit(articlePageSize).
+		Build()
+
+	// 画像のある記事がない場合は空のスライスを返却します。
+	articles := make([]*model.Article, 0, articlePageSize)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListWithImages: %w", err))
+	}
+
+	return limitArticles("ArticleListWithImages", articles, articlePageSize), nil
+}
+
+// ArticleLatest ...
+func ArticleLatest() (*model.Article, error) {
+	defer logSlowQuery("ArticleLatest", time.Now())
+
+	// 最も新しい公開中の記事を一件、筆者データと合わせて取得します。
+	// ArticleListByCursor() と同じく ID の降順を新しい順とし、一覧に表示しない記事は対象外です。
+	query := buildQuery(`SELECT
+		articles.id AS id,
+		articles.title AS title,
+		articles.body AS body,
+		articles.body_format AS body_format,
+		articles.status AS status,
+		articles.slug AS slug,
+		articles.featured_image_url AS featured_image_url,
+		articles.created AS created,
+		articles.updated AS updated,
+		COALESCE(articles.writer_id, 0) AS writer_id,
+		COALESCE(writers.id, 0) AS 'writer.id',
+		COALESCE(writers.name, '') AS 'writer.name',
+		COALESCE(writers.slug, '') AS 'writer.slug'
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	WHERE articles.status = ?
+	AND articles.noindex = 0
+	AND articles.deleted_at IS NULL
+	AND ` + hiddenTagFilter + `
+	ORDER BY articles.id desc
+	LIMIT 1;`)
+
+	var article model.Article
+	if err := getDB().Get(&article, query, model.ArticleStatusPublished); err != nil {
+		// 記事が一件もない場合は専用のエラーを返却します。
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleLatest: %w", err))
+	}
+
+	// タグデータを取得して記事の構造体に格納します。
+	tags, err := TagListByArticleID(article.ID)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleLatest: %w", err))
+	}
+	article.Tags = tags
+
+	return &article, nil
+}
+
+// ArticleListAlphabetical ...
+func ArticleListAlphabetical(afterTitle string, afterID int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListAlphabetical", time.Now())
+
+	// タイトルと ID の組み合わせをカーソルにして、タイトル・ID の昇順に 10 件取得します。
+	// 同じタイトルの記事が複数あっても ID で区別できるため、抜けや重複は発生しません。
+	// 最初のページはタイトルを空にして取得し、次のページは取得できた最後の記事のタイトルと ID を渡します。
+	// 並び順は title カラムの照合順序に従います（utf8mb4 の照合順序の場合、
+	// 日本語のタイトルは読みではなく文字コードに近い順になります）。
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("(title > ? OR (title = ? AND id > ?))", afterTitle, afterTitle, afterID).
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("noindex = 0").
+		Where("deleted_at IS NULL").
+		Where(hiddenTagFilter).
+		OrderBy("title, id").
+		Limit(10).
+		Build()
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListAlphabetical: %w", err))
+	}
+
+	return limitArticles("ArticleListAlphabetical", articles, articlePageSize), nil
+}
+
+// ArticleListByWriterAndStatus ...
+func ArticleListByWriterAndStatus(writerID int, status string, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByWriterAndStatus", time.Now())
+
+	// ステータスが指定されている場合は値をチェックします。
+	if status != "" && !model.IsValidArticleStatus(status) {
+		return nil, ErrInvalidStatus
+	}
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 筆者の記事を ID の降順に 10 件取得します。
+	// ステータスが空の場合はすべてのステータスの記事を取得します。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE writer_id = ?
+	AND (? = '' OR status = ?)
+	AND deleted_at IS NULL
+	AND id < ?
+	ORDER BY id desc
+	LIMIT 10`)
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, writerID, status, status, maxID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByWriterAndStatus: %w", err))
+	}
+
 	return articles, nil
 }
+
+// publishedAtOnPublish はステータスを変更する UPDATE 文で、公開日時を設定する式です。
+// SET 句で status を変更した後に評価され、公開された場合のみ公開日時がなければ 2 つ目のパラメータの日時を設定します。
+// パラメータには公開中のステータスと現在日時を指定します。
+const publishedAtOnPublish = "IF(status = ?, COALESCE(published_at, ?), published_at)"
+
+// ArticleSetStatus ...
+func ArticleSetStatus(id int, status string) error {
+	defer logSlowQuery("ArticleSetStatus", time.Now())
+
+	// ステータスの値をチェックします。
+	if !model.IsValidArticleStatus(status) {
+		return ErrInvalidStatus
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetStatus: %w", err))
+	}
+
+	// MySQL は値が変わらない場合に更新件数を 0 件と返すため、更新件数ではなく記事の状態をロックしながら確認します。
+	var deleted bool
+	q1 := buildQuery(`SELECT deleted_at IS NOT NULL FROM articles WHERE id = ? FOR UPDATE;`)
+	if err := tx.Get(&deleted, q1, id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrArticleNotFound
+		}
+		return ClassifyError(fmt.Errorf("ArticleSetStatus: %w", err))
+	}
+	if deleted {
+		tx.Rollback()
+		return ErrArticleDeleted
+	}
+
+	// 公開する場合は、初めて公開した日時を公開日時として記録します。
+	now := timeNow()
+	q2 := buildQuery(`UPDATE articles
+	SET status = ?, updated = ?, published_at = ` + publishedAtOnPublish + `
+	WHERE id = ?;`)
+	if _, err := tx.Exec(q2, status, now, model.ArticleStatusPublished, now, id); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSetStatus: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetStatus: %w", err))
+	}
+	return nil
+}
+
+// ArticleSetFeaturedImage ...
+func ArticleSetFeaturedImage(id int, imageURL string) error {
+	defer logSlowQuery("ArticleSetFeaturedImage", time.Now())
+
+	// URL の値をチェックします。空の場合はアイキャッチ画像を外します。
+	if err := model.ValidateFeaturedImageURL(imageURL); err != nil {
+		return err
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetFeaturedImage: %w", err))
+	}
+
+	// ArticleSetStatus() と同じく、更新件数ではなく記事の状態をロックしながら確認します。
+	var deleted bool
+	q1 := buildQuery(`SELECT deleted_at IS NOT NULL FROM articles WHERE id = ? FOR UPDATE;`)
+	if err := tx.Get(&deleted, q1, id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrArticleNotFound
+		}
+		return ClassifyError(fmt.Errorf("ArticleSetFeaturedImage: %w", err))
+	}
+	if deleted {
+		tx.Rollback()
+		return ErrArticleDeleted
+	}
+
+	q2 := buildQuery(`UPDATE articles SET featured_image_url = ?, updated = ? WHERE id = ?;`)
+	if _, err := tx.Exec(q2, imageURL, timeNow(), id); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSetFeaturedImage: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetFeaturedImage: %w", err))
+	}
+	return nil
+}
+
+// ArticleBulkSetStatus ...
+func ArticleBulkSetStatus(ids []int, status string) (int, error) {
+	defer logSlowQuery("ArticleBulkSetStatus", time.Now())
+
+	// ステータスの値をチェックします。
+	if !model.IsValidArticleStatus(status) {
+		return 0, ErrInvalidStatus
+	}
+
+	// 対象の記事が指定されていない場合は何もしません。
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// 複数の記事のステータスと更新日時を一回のクエリで更新します。
+	// 公開する場合は、初めて公開した日時を公開日時として記録します。
+	now := timeNow()
+	q1 := buildQuery(`UPDATE articles
+	SET status = ?, updated = ?, published_at = ` + publishedAtOnPublish + `
+	WHERE id IN(?);`)
+
+	q2, args, err := sqlx.In(q1, status, now, model.ArticleStatusPublished, now, ids)
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleBulkSetStatus: %w", err))
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleBulkSetStatus: %w", err))
+	}
+
+	res, err := tx.Exec(q2, args...)
+	if err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("ArticleBulkSetStatus: %w", err))
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("ArticleBulkSetStatus: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleBulkSetStatus: %w", err))
+	}
+
+	return int(n), nil
+}
+
+// ArticleListByTagID ...
+func ArticleListByTagID(tagID, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByTagID", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// articles_tags テーブルを結合して、タグが付いている公開中の記事を ID の降順に 10 件取得します。
+	query := buildQuery(`SELECT ` + articleColumnsQualified + `
+	FROM articles
+	INNER JOIN articles_tags AS at ON at.article_id = articles.id
+	WHERE at.tag_id = ?
+	AND articles.status = ?
+	AND articles.deleted_at IS NULL
+	AND articles.noindex = 0
+	AND ` + hiddenTagFilter + `
+	AND articles.id < ?
+	ORDER BY articles.id desc
+	LIMIT 10`)
+
+	// 存在しないタグの場合も空のスライスを返却します。
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, tagID, model.ArticleStatusPublished, maxID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByTagID: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleListByTagAndDateRange ...
+func ArticleListByTagAndDateRange(tagID int, from, to time.Time, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByTagAndDateRange", time.Now())
+
+	// 期間の開始日時は終了日時より前である必要があります。
+	if !from.Before(to) {
+		return nil, ErrInvalidDateRange
+	}
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// ArticleListByTagID() の条件に加えて、公開日時が期間内の記事に絞り込みます。
+	// 期間は開始日時を含み、終了日時を含みません（3 月の記事は 3/1 から 4/1 を指定します）。
+	query := buildQuery(`SELECT ` + articleColumnsQualified + `
+	FROM articles
+	INNER JOIN articles_tags AS at ON at.article_id = articles.id
+	WHERE at.tag_id = ?
+	AND ` + articlePublishedDate + ` >= ? AND ` + articlePublishedDate + ` < ?
+	AND articles.status = ?
+	AND articles.deleted_at IS NULL
+	AND articles.noindex = 0
+	AND ` + hiddenTagFilter + `
+	AND articles.id < ?
+	ORDER BY articles.id desc
+	LIMIT 10`)
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, tagID, from.UTC(), to.UTC(), model.ArticleStatusPublished, maxID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByTagAndDateRange: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleListByCursorExcluding ...
+func ArticleListByCursorExcluding(excludeID, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByCursorExcluding", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 閲覧中の記事を除いて、公開中の記事を ID の降順に 10 件取得します。
+	// SQL で除外するため、常に他の記事が 10 件取得できます。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE id < ? AND id <> ?
+	AND articles.status = ?
+	AND articles.deleted_at IS NULL
+	AND articles.noindex = 0
+	AND ` + hiddenTagFilter + `
+	ORDER BY id desc
+	LIMIT 10`)
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, maxID, excludeID, model.ArticleStatusPublished); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByCursorExcluding: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleUpdateReturning ...
+func ArticleUpdateReturning(article *model.Article) (*model.Article, error) {
+	defer logSlowQuery("ArticleUpdateReturning", time.Now())
+
+	// 保存する前に記事データの内容をチェックします。
+	if err := article.Validate(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdateReturning: %w", err))
+	}
+
+	// HTML 形式の本文は危険なタグを取り除いてから保存します。
+	if err := sanitizeBodyForUpdate(article); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdateReturning: %w", err))
+	}
+
+	// 本文から求める単語数とハッシュ値を設定します。
+	setDerivedColumns(article)
+
+	article.Updated = timeNow()
+
+	// ArticleUpdate() と同じカラムを更新します。
+	q1 := buildQuery(`UPDATE articles
+	SET title = :title,
+		body = :body,
+		body_format = COALESCE(NULLIF(:body_format, ''), body_format),
+		word_count = :word_count,
+		content_hash = :content_hash,
+		updated = :updated
+	WHERE id = :id AND deleted_at IS NULL;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdateReturning: %w", err))
+	}
+
+	if _, err := tx.NamedExec(q1, article); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdateReturning: %w", err))
+	}
+
+	// 同じトランザクション内で更新後の記事データを取得し直します。
+	// 更新件数は値が変わらない場合に 0 件になるため、取得できたかどうかで存在を判定します。
+	// ゴミ箱に入っている記事は ArticleUpdate() と同じく更新せず、専用のエラーを返却します。
+	var updated model.Article
+	if err := tx.Get(&updated, buildQuery(`SELECT `+articleColumns+` FROM articles WHERE id = ?;`), article.ID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdateReturning: %w", err))
+	}
+	if updated.DeletedAt != nil {
+		tx.Rollback()
+		return nil, ErrArticleDeleted
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleUpdateReturning: %w", err))
+	}
+
+	return &updated, nil
+}
+
+// ArticleListByLang ...
+func ArticleListByLang(lang string, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByLang", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 指定した言語の公開中の記事を ID の降順に 10 件取得します。
+	// 言語が空の場合はすべての言語の記事を取得します。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE (? = '' OR lang = ?)
+	AND id < ?
+	AND articles.status = ?
+	AND articles.deleted_at IS NULL
+	AND articles.noindex = 0
+	AND ` + hiddenTagFilter + `
+	ORDER BY id desc
+	LIMIT 10`)
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, lang, lang, maxID, model.ArticleStatusPublished); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByLang: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleCountDeletedKey は ArticleCountByStatus() でゴミ箱に入っている記事の件数を格納するキーです。
+const ArticleCountDeletedKey = "deleted"
+
+// ArticleCountByStatus ...
+func ArticleCountByStatus() (map[string]int, error) {
+	defer logSlowQuery("ArticleCountByStatus", time.Now())
+
+	// ステータスごとの件数とゴミ箱の件数を、条件付きの集計で一回のクエリで取得します。
+	// ゴミ箱に入っている記事はステータスに関わらずゴミ箱の件数にのみ含めます。
+	// 記事が一件もない場合に NULL にならないよう、COALESCE 関数で初期値を指定しています。
+	query := buildQuery(`SELECT
+		COALESCE(SUM(deleted_at IS NULL AND status = ?), 0) AS draft,
+		COALESCE(SUM(deleted_at IS NULL AND status = ?), 0) AS published,
+		COALESCE(SUM(deleted_at IS NOT NULL), 0) AS deleted
+	FROM articles;`)
+
+	var counts struct {
+		Draft     int `db:"draft"`
+		Published int `db:"published"`
+		Deleted   int `db:"deleted"`
+	}
+	if err := getDB().Get(&counts, query, model.ArticleStatusDraft, model.ArticleStatusPublished); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCountByStatus: %w", err))
+	}
+
+	return map[string]int{
+		model.ArticleStatusDraft:     counts.Draft,
+		model.ArticleStatusPublished: counts.Published,
+		ArticleCountDeletedKey:       counts.Deleted,
+	}, nil
+}
+
+// ArticleListModifiedSince ...
+func ArticleListModifiedSince(since time.Time, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListModifiedSince", time.Now())
+
+	// 更新日時と ID の組み合わせをカーソルにして、更新日時・ID の昇順に 10 件取得します。
+	// 最初のページはカーソルに 0 を渡し、since より後に更新された記事を取得します。
+	// 次のページは、取得できた最後の記事の更新日時を since に、ID を cursor に渡します。
+	// 同じ更新日時の記事が複数あっても ID で区別できるため、抜けや重複は発生しません。
+	// 検索インデックスから削除できるように、ゴミ箱に入っている記事も取得します。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE updated > ? OR (? > 0 AND updated = ? AND id > ?)
+	ORDER BY updated, id
+	LIMIT 10`)
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, since, cursor, since, cursor); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListModifiedSince: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleSetNoIndex ...
+func ArticleSetNoIndex(id int, noindex bool) error {
+	defer logSlowQuery("ArticleSetNoIndex", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetNoIndex: %w", err))
+	}
+
+	// ArticleSetStatus() と同じく、更新件数ではなく記事の状態をロックしながら確認します。
+	var deleted bool
+	q1 := buildQuery(`SELECT deleted_at IS NOT NULL FROM articles WHERE id = ? FOR UPDATE;`)
+	if err := tx.Get(&deleted, q1, id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrArticleNotFound
+		}
+		return ClassifyError(fmt.Errorf("ArticleSetNoIndex: %w", err))
+	}
+	if deleted {
+		tx.Rollback()
+		return ErrArticleDeleted
+	}
+
+	q2 := buildQuery(`UPDATE articles SET noindex = ? WHERE id = ?;`)
+	if _, err := tx.Exec(q2, noindex, id); err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSetNoIndex: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetNoIndex: %w", err))
+	}
+	return nil
+}
+
+// ArticleListSitemap ...
+func ArticleListSitemap() ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListSitemap", time.Now())
+
+	// サイトマップに掲載する公開中の記事の URL の生成に必要なカラムのみを取得します。
+	// noindex が設定された記事は検索エンジンに登録しないため含めません。
+	query := buildQuery(`SELECT id, slug, updated
+	FROM articles
+	WHERE status = ?
+	AND deleted_at IS NULL
+	AND noindex = 0
+	AND ` + hiddenTagFilter + `
+	ORDER BY id desc;`)
+
+	var articles []*model.Article
+	if err := getDB().Select(&articles, query, model.ArticleStatusPublished); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListSitemap: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleUpdateIfUnmodified ...
+func ArticleUpdateIfUnmodified(article *model.Article, knownUpdated time.Time) error {
+	defer logSlowQuery("ArticleUpdateIfUnmodified", time.Now())
+
+	// 保存する前に記事データの内容をチェックします。
+	if err := article.Validate(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleUpdateIfUnmodified: %w", err))
+	}
+
+	// HTML 形式の本文は危険なタグを取り除いてから保存します。
+	if err := sanitizeBodyForUpdate(article); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleUpdateIfUnmodified: %w", err))
+	}
+
+	// 更新日時は秒単位で保存されているため、比較する値も秒単位に揃えます。
+	knownUpdated = knownUpdated.UTC().Truncate(time.Second)
+
+	// 他の処理で更新されていないことを確認するため、更新日時が一致する場合のみ更新します。
+	// ゴミ箱に入っている記事は ArticleUpdate() と同じく更新しません。
+	query := buildQuery(`UPDATE articles
+	SET title = ?,
+		body = ?,
+		body_format = COALESCE(NULLIF(?, ''), body_format),
+		word_count = ?,
+		content_hash = ?,
+		updated = ?
+	WHERE id = ? AND updated = ? AND deleted_at IS NULL;`)
+
+	// 本文から求める単語数とハッシュ値を設定します。
+	setDerivedColumns(article)
+
+	updated := timeNow()
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleUpdateIfUnmodified: %w", err))
+	}
+
+	res, err := tx.Exec(query, article.Title, article.Body, article.BodyFormat,
+		article.WordCount, article.ContentHash, updated, article.ID, knownUpdated)
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleUpdateIfUnmodified: %w", err))
+	}
+
+	// 一致する記事がない場合は、記事が存在しないかゴミ箱に入っているか、他の処理で更新されています。
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+
+		var deleted bool
+		err := getDB().Get(&deleted, buildQuery(`SELECT deleted_at IS NOT NULL FROM articles WHERE id = ?;`), article.ID)
+		if err == sql.ErrNoRows {
+			return ErrArticleNotFound
+		}
+		if err != nil {
+			return ClassifyError(fmt.Errorf("ArticleUpdateIfUnmodified: %w", err))
+		}
+		if deleted {
+			return ErrArticleDeleted
+		}
+		return ErrConcurrentModification
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleUpdateIfUnmodified: %w", err))
+	}
+
+	article.Updated = updated
+	return nil
+}
+
+// ArticleGetByPreviewToken ...
+func ArticleGetByPreviewToken(token string) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetByPreviewToken", time.Now())
+
+	// トークンが設定されていない記事に空文字で一致しないよう、空の場合は取得しません。
+	if token == "" {
+		return nil, ErrArticleNotFound
+	}
+
+	// ステータスに関わらず、トークンが一致する記事を取得します。
+	// ゴミ箱に入っている記事はプレビューできません。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE preview_token = ? AND deleted_at IS NULL;`)
+
+	var article model.Article
+	if err := getDB().Get(&article, query, token); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleGetByPreviewToken: %w", err))
+	}
+
+	return &article, nil
+}
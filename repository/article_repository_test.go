@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArticleDeleteRemovesTags(t *testing.T) {
+	d := NewTestDB(t)
+
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+
+	var tagIDs []int
+	for _, name := range []string{"go", "mysql"} {
+		tag, err := TagCreate(name)
+		if err != nil {
+			t.Fatalf("TagCreate(%q): %v", name, err)
+		}
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	if err := ArticleSetTags(article.ID, tagIDs); err != nil {
+		t.Fatalf("ArticleSetTags: %v", err)
+	}
+
+	var count int
+	if err := d.Get(&count, `SELECT COUNT(*) FROM articles_tags WHERE article_id = ?;`, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("articles_tags before delete = %d, want 2", count)
+	}
+
+	if err := ArticleDelete(article.ID); err != nil {
+		t.Fatalf("ArticleDelete: %v", err)
+	}
+
+	if err := d.Get(&count, `SELECT COUNT(*) FROM articles_tags;`); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("articles_tags after delete = %d, want 0", count)
+	}
+}
+
+func TestArticleGetByIDContextCancelSlowQuery(t *testing.T) {
+	d := NewTestDB(t)
+
+	// 別の接続で articles テーブルをロックし、記事の取得がロックの解放を待ち続ける状態にします。
+	lock, err := d.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lock.ExecContext(context.Background(), `LOCK TABLES articles WRITE;`); err != nil {
+		lock.Close()
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = ArticleGetByIDContext(ctx, 1)
+	elapsed := time.Since(start)
+
+	lock.ExecContext(context.Background(), `UNLOCK TABLES;`)
+	lock.Close()
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ArticleGetByIDContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("ArticleGetByIDContext() returned after %v, want promptly after cancel", elapsed)
+	}
+
+	// 中断したクエリの接続がプールに返却（または破棄）され、使用中のまま残らないことを確認します。
+	deadline := time.Now().Add(time.Second)
+	for d.Stats().InUse > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if inUse := d.Stats().InUse; inUse != 0 {
+		t.Errorf("connections in use = %d, want 0", inUse)
+	}
+}
+
+func TestArticleUpdateKeepsUnsentFields(t *testing.T) {
+	NewTestDB(t)
+
+	article := &model.Article{
+		Title:            "title",
+		Body:             "body",
+		Status:           model.ArticleStatusDraft,
+		FeaturedImageURL: "https://example.com/image.png",
+	}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+
+	// フォームから送信される項目（タイトルと本文）のみを設定して更新します。
+	if _, err := ArticleUpdate(&model.Article{ID: article.ID, Title: "new title", Body: "new body"}, false); err != nil {
+		t.Fatalf("ArticleUpdate: %v", err)
+	}
+
+	got, err := ArticleGetForEdit(article.ID)
+	if err != nil {
+		t.Fatalf("ArticleGetForEdit: %v", err)
+	}
+	if got.Title != "new title" || got.Body != "new body" {
+		t.Errorf("title, body = %q, %q, want updated values", got.Title, got.Body)
+	}
+	if got.Status != model.ArticleStatusDraft {
+		t.Errorf("status = %q, want %q", got.Status, model.ArticleStatusDraft)
+	}
+	if got.FeaturedImageURL != article.FeaturedImageURL {
+		t.Errorf("featured_image_url = %q, want %q", got.FeaturedImageURL, article.FeaturedImageURL)
+	}
+}
+
+func TestArticleSetFeaturedImage(t *testing.T) {
+	NewTestDB(t)
+
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+
+	if err := ArticleSetFeaturedImage(article.ID, "https://example.com/image.png"); err != nil {
+		t.Fatalf("ArticleSetFeaturedImage: %v", err)
+	}
+	got, err := ArticleGetForEdit(article.ID)
+	if err != nil {
+		t.Fatalf("ArticleGetForEdit: %v", err)
+	}
+	if got.FeaturedImageURL != "https://example.com/image.png" {
+		t.Errorf("featured_image_url = %q", got.FeaturedImageURL)
+	}
+
+	var verr *model.ValidationError
+	if err := ArticleSetFeaturedImage(article.ID, "not a url"); !errors.As(err, &verr) {
+		t.Errorf("ArticleSetFeaturedImage(invalid) error = %v, want *model.ValidationError", err)
+	}
+	if err := ArticleSetFeaturedImage(article.ID+1, ""); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("ArticleSetFeaturedImage(unknown) error = %v, want ErrArticleNotFound", err)
+	}
+}
+
+func TestArticleUpdateTrashed(t *testing.T) {
+	NewTestDB(t)
+
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+	if err := ArticleTrash(article.ID); err != nil {
+		t.Fatalf("ArticleTrash: %v", err)
+	}
+
+	// ゴミ箱に入っている記事は、どの更新処理でも編集できないことを確認します。
+	edit := func() *model.Article {
+		return &model.Article{ID: article.ID, Title: "new title", Body: "new body"}
+	}
+	if _, err := ArticleUpdate(edit(), false); !errors.Is(err, ErrArticleDeleted) {
+		t.Errorf("ArticleUpdate() error = %v, want ErrArticleDeleted", err)
+	}
+	if _, err := ArticleUpdateReturning(edit()); !errors.Is(err, ErrArticleDeleted) {
+		t.Errorf("ArticleUpdateReturning() error = %v, want ErrArticleDeleted", err)
+	}
+	if err := ArticleUpdateIfUnmodified(edit(), article.Updated); !errors.Is(err, ErrArticleDeleted) {
+		t.Errorf("ArticleUpdateIfUnmodified() error = %v, want ErrArticleDeleted", err)
+	}
+
+	got, err := ArticleGetByIDIncludingDeleted(article.ID)
+	if err != nil {
+		t.Fatalf("ArticleGetByIDIncludingDeleted: %v", err)
+	}
+	if got.Title != "title" {
+		t.Errorf("title = %q, want the trashed article to be unchanged", got.Title)
+	}
+}
+
+func TestArticleListPagingSameSecond(t *testing.T) {
+	d := NewTestDB(t)
+
+	const total = 25
+	for i := 0; i < total; i++ {
+		if _, err := ArticleCreate(&model.Article{Title: "title", Body: "body"}); err != nil {
+			t.Fatalf("ArticleCreate: %v", err)
+		}
+	}
+
+	// すべての記事の作成日時と更新日時を同じ秒に揃えます。
+	same := time.Date(2021, 12, 1, 9, 0, 0, 0, time.UTC)
+	d.MustExec(`UPDATE articles SET created = ?, updated = ?;`, same, same)
+
+	t.Run("ArticleListByCursor", func(t *testing.T) {
+		seen := map[int]bool{}
+		cursor := 0
+		for {
+			page, err := ArticleListByCursor(cursor)
+			if err != nil {
+				t.Fatalf("ArticleListByCursor: %v", err)
+			}
+			if len(page) == 0 {
+				break
+			}
+			for _, article := range page {
+				if seen[article.ID] {
+					t.Errorf("article %d appears twice", article.ID)
+				}
+				seen[article.ID] = true
+			}
+			cursor = page[len(page)-1].ID
+		}
+		if len(seen) != total {
+			t.Errorf("paged %d articles, want %d", len(seen), total)
+		}
+	})
+
+	t.Run("ArticleListModifiedSince", func(t *testing.T) {
+		// 更新日時が同じ記事は ID で区別されるため、抜けや重複なくページを送れます。
+		seen := map[int]bool{}
+		since, cursor := same.Add(-time.Second), 0
+		for {
+			page, err := ArticleListModifiedSince(since, cursor)
+			if err != nil {
+				t.Fatalf("ArticleListModifiedSince: %v", err)
+			}
+			if len(page) == 0 {
+				break
+			}
+			for _, article := range page {
+				if seen[article.ID] {
+					t.Errorf("article %d appears twice", article.ID)
+				}
+				seen[article.ID] = true
+			}
+			last := page[len(page)-1]
+			since, cursor = last.Updated, last.ID
+		}
+		if len(seen) != total {
+			t.Errorf("paged %d articles, want %d", len(seen), total)
+		}
+	})
+}
+
+func TestArticleCreateStoresUTC(t *testing.T) {
+	d := NewTestDB(t)
+
+	// サーバーのタイムゾーンが UTC 以外の場合も、日時は UTC で保存します。
+	jst := time.FixedZone("JST", 9*60*60)
+	local := time.Local
+	time.Local = jst
+	t.Cleanup(func() {
+		time.Local = local
+	})
+
+	before := time.Now().UTC().Truncate(time.Second)
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+	after := time.Now().UTC()
+
+	// ドライバーのタイムゾーンの変換を通さずに、保存されている値をそのまま読み出します。
+	var raw string
+	if err := d.Get(&raw, `SELECT DATE_FORMAT(created, '%Y-%m-%d %H:%i:%s') FROM articles WHERE id = ?;`, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := time.ParseInLocation("2006-01-02 15:04:05", raw, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Before(before) || stored.After(after) {
+		t.Errorf("stored created = %s, want UTC between %s and %s", raw, before, after)
+	}
+
+	got, err := ArticleGetByID(article.ID)
+	if err != nil {
+		t.Fatalf("ArticleGetByID: %v", err)
+	}
+	if !got.Created.Equal(stored) {
+		t.Errorf("Created = %s, want %s", got.Created, stored)
+	}
+	if h := got.CreatedIn(jst).Hour(); h != stored.Add(9*time.Hour).Hour() {
+		t.Errorf("CreatedIn(JST).Hour() = %d, want %d", h, stored.Add(9*time.Hour).Hour())
+	}
+}
+
+func TestArticleTouch(t *testing.T) {
+	d := NewTestDB(t)
+
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+
+	// 作成日時と更新日時を過去の日時にしておき、更新日時のみが変わることを確認します。
+	past := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := d.Exec(`UPDATE articles SET created = ?, updated = ? WHERE id = ?;`, past, past, article.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now().UTC().Truncate(time.Second)
+	if err := ArticleTouch(article.ID); err != nil {
+		t.Fatalf("ArticleTouch: %v", err)
+	}
+	// 同じ秒の間に続けて呼んでも、記事が見つからないとは判定しません。
+	if err := ArticleTouch(article.ID); err != nil {
+		t.Fatalf("ArticleTouch (same second): %v", err)
+	}
+
+	got, err := ArticleGetByID(article.ID)
+	if err != nil {
+		t.Fatalf("ArticleGetByID: %v", err)
+	}
+	if !got.Created.Equal(past) {
+		t.Errorf("Created = %s, want %s", got.Created, past)
+	}
+	if got.Updated.Before(before) {
+		t.Errorf("Updated = %s, want at or after %s", got.Updated, before)
+	}
+	if got.Title != "title" || got.Body != "body" {
+		t.Errorf("Title, Body = %q, %q, want unchanged", got.Title, got.Body)
+	}
+
+	if err := ArticleTouch(article.ID + 1); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("ArticleTouch(unknown id) error = %v, want ErrArticleNotFound", err)
+	}
+}
+
+func TestArticleListFullWithTagsQueryCount(t *testing.T) {
+	NewTestDB(t)
+
+	tag, err := TagCreate("go")
+	if err != nil {
+		t.Fatalf("TagCreate: %v", err)
+	}
+
+	created := 0
+	for _, n := range []int{1, 20} {
+		for ; created < n; created++ {
+			article := &model.Article{Title: fmt.Sprintf("title %d", created), Body: "body"}
+			if _, err := ArticleCreate(article); err != nil {
+				t.Fatalf("ArticleCreate: %v", err)
+			}
+			if err := ArticleSetTags(article.ID, []int{tag.ID}); err != nil {
+				t.Fatalf("ArticleSetTags: %v", err)
+			}
+		}
+
+		// 閾値を負の値にしてすべてのリポジトリ関数の呼び出しをログに出力し、発行したクエリを数えます。
+		var buf bytes.Buffer
+		SetSlowQueryLogger(-1, log.New(&buf, "", 0))
+		articles, err := ArticleListFullWithTags()
+		SetSlowQueryLogger(0, nil)
+		if err != nil {
+			t.Fatalf("ArticleListFullWithTags: %v", err)
+		}
+
+		if len(articles) != n {
+			t.Fatalf("ArticleListFullWithTags() = %d articles, want %d", len(articles), n)
+		}
+		for _, a := range articles {
+			if len(a.Tags) != 1 || a.Tags[0].ID != tag.ID {
+				t.Errorf("article %d Tags = %v, want [%d]", a.ID, a.Tags, tag.ID)
+			}
+		}
+
+		// 記事の件数に関わらず、記事の一覧とタグ情報の二回のクエリのみを発行します。
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 ||
+			!strings.Contains(lines[0], "TagListMapByArticleIDs") ||
+			!strings.Contains(lines[1], "ArticleListFullWithTags") {
+			t.Errorf("%d articles: queries = %q, want TagListMapByArticleIDs and ArticleListFullWithTags only", n, lines)
+		}
+	}
+}
+
+func TestArticleGetByIDIncludingDeleted(t *testing.T) {
+	NewTestDB(t)
+
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+	if err := ArticleTrash(article.ID); err != nil {
+		t.Fatalf("ArticleTrash: %v", err)
+	}
+
+	// ゴミ箱に入っている記事も取得できます。
+	got, err := ArticleGetByIDIncludingDeleted(article.ID)
+	if err != nil {
+		t.Fatalf("ArticleGetByIDIncludingDeleted: %v", err)
+	}
+	if got.ID != article.ID || got.DeletedAt == nil {
+		t.Errorf("ArticleGetByIDIncludingDeleted() = id %d, deleted_at %v, want the trashed article", got.ID, got.DeletedAt)
+	}
+
+	if _, err := ArticleGetByIDIncludingDeleted(article.ID + 1); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("ArticleGetByIDIncludingDeleted(unknown id) error = %v, want ErrArticleNotFound", err)
+	}
+}
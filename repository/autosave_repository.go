@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+)
+
+// ErrAutosaveNotFound ...
+var ErrAutosaveNotFound = errors.New("autosave not found")
+
+// AutosaveUpsert ...
+func AutosaveUpsert(writerID, articleID int, title, body string) error {
+	defer logSlowQuery("AutosaveUpsert", time.Now())
+
+	// 自動保存は筆者と記事の組み合わせごとに一件だけ保持し、最新の内容で上書きします。
+	query := buildQuery(`INSERT INTO autosaves (writer_id, article_id, title, body, updated)
+	VALUES (?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		title = VALUES(title),
+		body = VALUES(body),
+		updated = VALUES(updated);`)
+
+	if _, err := getDB().Exec(query, writerID, articleID, title, body, timeNow()); err != nil {
+		return ClassifyError(fmt.Errorf("AutosaveUpsert: %w", err))
+	}
+	return nil
+}
+
+// AutosaveGet ...
+func AutosaveGet(writerID, articleID int) (*model.Autosave, error) {
+	defer logSlowQuery("AutosaveGet", time.Now())
+
+	query := buildQuery(`SELECT * FROM autosaves WHERE writer_id = ? AND article_id = ?;`)
+
+	var autosave model.Autosave
+	if err := getDB().Get(&autosave, query, writerID, articleID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAutosaveNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("AutosaveGet: %w", err))
+	}
+
+	return &autosave, nil
+}
+
+// AutosavePublish ...
+func AutosavePublish(writerID, articleID int) error {
+	defer logSlowQuery("AutosavePublish", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("AutosavePublish: %w", err))
+	}
+
+	// 他の保存処理と競合しないよう、自動保存の内容をロックして取得します。
+	var autosave model.Autosave
+	q1 := buildQuery(`SELECT * FROM autosaves WHERE writer_id = ? AND article_id = ? FOR UPDATE;`)
+	if err := tx.Get(&autosave, q1, writerID, articleID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrAutosaveNotFound
+		}
+		return ClassifyError(fmt.Errorf("AutosavePublish: %w", err))
+	}
+
+	// 本文は記事の形式に合わせてサニタイズするため、筆者の記事であることを確認して形式を取得します。
+	var format string
+	q2 := buildQuery(`SELECT body_format FROM articles WHERE id = ? AND writer_id = ? AND deleted_at IS NULL FOR UPDATE;`)
+	if err := tx.Get(&format, q2, articleID, writerID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrArticleNotFound
+		}
+		return ClassifyError(fmt.Errorf("AutosavePublish: %w", err))
+	}
+
+	article := &model.Article{
+		ID:         articleID,
+		Title:      autosave.Title,
+		Body:       autosave.Body,
+		BodyFormat: format,
+	}
+	sanitizeBody(article)
+	setDerivedColumns(article)
+
+	// 自動保存の内容を記事に反映して公開します。
+	q3 := buildQuery(`UPDATE articles
+	SET title = ?,
+		body = ?,
+		status = ?,
+		word_count = ?,
+		content_hash = ?,
+		updated = ?,
+		published_at = ` + publishedAtOnPublish + `
+	WHERE id = ?;`)
+	now := timeNow()
+	if _, err := tx.Exec(q3, article.Title, article.Body, model.ArticleStatusPublished, article.WordCount, article.ContentHash, now, model.ArticleStatusPublished, now, article.ID); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("AutosavePublish: %w", err))
+	}
+
+	// 反映した自動保存は削除します。
+	q4 := buildQuery(`DELETE FROM autosaves WHERE writer_id = ? AND article_id = ?;`)
+	if _, err := tx.Exec(q4, writerID, articleID); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("AutosavePublish: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("AutosavePublish: %w", err))
+	}
+	return nil
+}
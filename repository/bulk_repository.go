@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// BulkError は一括処理で失敗した入力の位置とエラーの内容です。
+type BulkError struct {
+	Index int
+	Err   error
+}
+
+// Error ...
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("article at index %d: %v", e.Index, e.Err)
+}
+
+// Unwrap ...
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// ArticleBulkCreate ...
+func ArticleBulkCreate(articles []*model.Article, continueOnError bool) ([]BulkError, error) {
+	defer logSlowQuery("ArticleBulkCreate", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleBulkCreate: %w", err))
+	}
+
+	// continueOnError が true の場合は、作成できた記事のみをコミットして失敗した記事の一覧を返却します。
+	var failed []BulkError
+	for i, article := range articles {
+		err, fatal := bulkInsertArticle(tx, article, continueOnError)
+		if fatal != nil {
+			tx.Rollback()
+			return nil, fatal
+		}
+		if err == nil {
+			continue
+		}
+
+		// デフォルトでは一件でも失敗した場合はすべての記事の作成を取り消します。
+		if !continueOnError {
+			tx.Rollback()
+			return nil, &BulkError{Index: i, Err: err}
+		}
+
+		// 失敗した記事を記録して、残りの記事の作成を続けます。
+		failed = append(failed, BulkError{Index: i, Err: err})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleBulkCreate: %w", err))
+	}
+
+	return failed, nil
+}
+
+// bulkInsertArticle は一括作成の一件分の記事を作成します。
+// 記事の内容が不正な場合などの一件分のエラーは err、処理全体を中断するエラーは fatal で返却します。
+// savepoint が true の場合は、失敗した記事の作成のみを取り消せるようセーブポイントを利用します。
+func bulkInsertArticle(tx *sqlx.Tx, article *model.Article, savepoint bool) (err, fatal error) {
+	// 未指定の項目に初期値を設定し、内容をチェックします。
+	if err := prepareArticleCreate(article); err != nil {
+		return err, nil
+	}
+
+	if !savepoint {
+		_, err := insertArticle(tx, article)
+		return err, nil
+	}
+
+	if _, err := tx.Exec(`SAVEPOINT bulk_article;`); err != nil {
+		return nil, err
+	}
+	if _, err := insertArticle(tx, article); err != nil {
+		if _, rerr := tx.Exec(`ROLLBACK TO SAVEPOINT bulk_article;`); rerr != nil {
+			return nil, rerr
+		}
+		return err, nil
+	}
+	return nil, nil
+}
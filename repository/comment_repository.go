@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ArticleCommentCounts ...
+func ArticleCommentCounts(articleIDs []int) (map[int]int, error) {
+	defer logSlowQuery("ArticleCommentCounts", time.Now())
+
+	// コメント数を格納するマップを生成します。
+	// マップのキーに記事ID、バリューにコメント数を格納します。
+	m := make(map[int]int, len(articleIDs))
+
+	// 引数で渡ってきたスライスのサイズが 0 の場合は即時リターンします。
+	if len(articleIDs) == 0 {
+		return m, nil
+	}
+
+	// コメントが一件もない記事も 0 件として返却するために、先に初期値を設定しておきます。
+	for _, id := range articleIDs {
+		m[id] = 0
+	}
+
+	// 記事ごとのコメント数を一度のクエリでまとめて取得します。
+	q1 := buildQuery(`SELECT article_id, COUNT(*) AS count
+	FROM comments
+	WHERE article_id IN(?)
+	GROUP BY article_id;`)
+
+	q2, args, err := sqlx.In(q1, articleIDs)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCommentCounts: %w", err))
+	}
+
+	var counts []struct {
+		ArticleID int `db:"article_id"`
+		Count     int `db:"count"`
+	}
+	if err := getDB().Select(&counts, q2, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCommentCounts: %w", err))
+	}
+
+	// 取得したデータを map に格納し直します。
+	for _, c := range counts {
+		m[c.ArticleID] = c.Count
+	}
+
+	return m, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// 接続が切れている場合に、再接続を待つ間隔の初期値と試行回数です。
+// 待つ間隔は試行するごとに倍にします（100ms, 200ms, 400ms, ...）。
+const (
+	reconnectInitialBackoff = 100 * time.Millisecond
+	reconnectAttempts       = 5
+)
+
+// PingInterval はコネクションプールの接続を維持するために DB に ping を送る間隔です。
+var PingInterval = 30 * time.Second
+
+// isBadConnection はエラーが DB との接続が切れたことによるものかを判定します。
+// MySQL の再起動後などに、プールに残っていた古い接続を利用すると発生します。
+func isBadConnection(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "bad connection") || strings.Contains(msg, "invalid connection")
+}
+
+// waitForConnection は DB に接続できるようになるまで、間隔を空けながら ping を送ります。
+func waitForConnection() error {
+	backoff := reconnectInitialBackoff
+	var err error
+	for i := 0; i < reconnectAttempts; i++ {
+		if err = getDB().Ping(); err == nil {
+			return nil
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return err
+}
+
+// ErrShuttingDown ...
+var ErrShuttingDown = errors.New("repository is shutting down")
+
+// closing は Close() が呼ばれて DB のハンドルを閉じている最中かどうかです。
+var closing int32
+
+// isClosing は Close() が呼ばれたかどうかを返却します。
+func isClosing() bool {
+	return atomic.LoadInt32(&closing) == 1
+}
+
+// beginTx はトランザクションを開始します。
+// 接続が切れていて開始できない場合は、再接続を待ってから一度だけやり直します。
+// シャットダウン中の場合は、閉じた DB のハンドルで panic しないよう ErrShuttingDown を返却します。
+func beginTx() (*sqlx.Tx, error) {
+	if isClosing() {
+		return nil, ErrShuttingDown
+	}
+
+	tx, err := getDB().Beginx()
+	if isBadConnection(err) && !isClosing() {
+		if werr := waitForConnection(); werr == nil {
+			tx, err = getDB().Beginx()
+		}
+	}
+	if err != nil {
+		// 開始する間に Close() が呼ばれた場合は、DB を閉じたことによるエラーになります。
+		if isClosing() {
+			return nil, ErrShuttingDown
+		}
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Close ...
+func Close() error {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	// 以降に開始されるトランザクションは、DB を閉じる前に ErrShuttingDown で失敗させます。
+	atomic.StoreInt32(&closing, 1)
+
+	// ping を送る処理を停止します。
+	startPinger(nil)
+
+	if db == nil {
+		return nil
+	}
+	return db.Close()
+}
+
+// pingerCancel は起動中の ping を送る処理を停止する関数です。
+var pingerCancel context.CancelFunc
+
+// startPinger はコネクションプールの接続が切れたままにならないよう、定期的に ping を送る処理を起動します。
+// Init() から呼び出し、既に起動している場合は停止してから起動し直します。
+func startPinger(d *sqlx.DB) {
+	if pingerCancel != nil {
+		pingerCancel()
+		pingerCancel = nil
+	}
+	if d == nil || PingInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pingerCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(PingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.PingContext(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("db ping failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// ErrUnavailable ...
+var ErrUnavailable = errors.New("database is unavailable")
+
+// unavailableError は DB に接続できないことによるエラーです。
+// errors.Is(err, ErrUnavailable) で判定でき、元のエラーも errors.Is や errors.As で取り出せます。
+type unavailableError struct {
+	err error
+}
+
+// Error ...
+func (e *unavailableError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap ...
+func (e *unavailableError) Unwrap() error {
+	return e.err
+}
+
+// Is ...
+func (e *unavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// ClassifyError ...
+func ClassifyError(err error) error {
+	// DB に接続できないことによるエラーの場合は、ErrUnavailable として判定できるようにします。
+	// 呼び出し元では ErrUnavailable の場合は一時的な障害（503）、それ以外はクエリなどの不具合（500）として扱えます。
+	if err == nil || errors.Is(err, ErrUnavailable) || !isUnavailable(err) {
+		return err
+	}
+	return &unavailableError{err: err}
+}
+
+// isUnavailable はエラーが DB に接続できないことによるものかを判定します。
+// 接続の切断に加えて、接続が拒否された場合やタイムアウトした場合などのネットワークのエラーも対象にします。
+func isUnavailable(err error) bool {
+	if isBadConnection(err) || errors.Is(err, ErrShuttingDown) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, syscall.ECONNREFUSED)
+}
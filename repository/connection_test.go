@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// timeoutError は接続のタイムアウトを表す net.Error です。
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestClassifyErrorUnavailable(t *testing.T) {
+	// DB に接続できないことによるエラーは、ラップされていても ErrUnavailable として判定できます。
+	tests := []error{
+		driver.ErrBadConn,
+		mysql.ErrInvalidConn,
+		timeoutError{},
+		&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+		syscall.ECONNREFUSED,
+		ErrShuttingDown,
+	}
+	for _, err := range tests {
+		wrapped := fmt.Errorf("ArticleGetByID: %w", err)
+		got := ClassifyError(wrapped)
+		if !errors.Is(got, ErrUnavailable) {
+			t.Errorf("ClassifyError(%v) is not ErrUnavailable", err)
+		}
+		// 元のエラーも取り出せることを確認します。
+		if !errors.Is(got, err) {
+			t.Errorf("ClassifyError(%v) does not wrap the original error", err)
+		}
+		if got.Error() != wrapped.Error() {
+			t.Errorf("ClassifyError(%v).Error() = %q, want %q", err, got.Error(), wrapped.Error())
+		}
+		// すでに判定済みのエラーは、重ねてラップしません。
+		if again := ClassifyError(got); again != got {
+			t.Errorf("ClassifyError(ClassifyError(%v)) wrapped the error again", err)
+		}
+	}
+}
+
+func TestClassifyErrorQuery(t *testing.T) {
+	// クエリの不具合などのエラーは ErrUnavailable として判定せず、そのまま返却します。
+	tests := []error{
+		&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'go' for key 'uq_tags_name'"},
+		&mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"},
+		ErrArticleNotFound,
+		errors.New("sql: no rows in result set"),
+	}
+	for _, err := range tests {
+		wrapped := fmt.Errorf("ArticleGetByID: %w", err)
+		got := ClassifyError(wrapped)
+		if errors.Is(got, ErrUnavailable) {
+			t.Errorf("ClassifyError(%v) is ErrUnavailable", err)
+		}
+		if got != wrapped {
+			t.Errorf("ClassifyError(%v) = %v, want the error unchanged", err, got)
+		}
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(ClassifyError(fmt.Errorf("TagCreate: %w", tests[0])), &mysqlErr) || mysqlErr.Number != 1062 {
+		t.Errorf("ClassifyError() does not keep the *mysql.MySQLError")
+	}
+
+	if got := ClassifyError(nil); got != nil {
+		t.Errorf("ClassifyError(nil) = %v, want nil", got)
+	}
+}
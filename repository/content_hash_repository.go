@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ArticleFindByContentHash ...
+func ArticleFindByContentHash(hash string) (*model.Article, error) {
+	defer logSlowQuery("ArticleFindByContentHash", time.Now())
+
+	// インポートする記事と同じ内容の記事があるかを確認するために利用します。
+	// ハッシュ値は model.ContentHash() でタイトルと本文から求めます。
+	// 同じ内容の記事が複数ある場合は、最初に作成された記事を返却します。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE content_hash = ?
+	ORDER BY id
+	LIMIT 1;`)
+
+	var article model.Article
+	if err := getDB().Get(&article, query, hash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleFindByContentHash: %w", err))
+	}
+
+	return &article, nil
+}
+
+// RecomputeContentHashes ...
+func RecomputeContentHashes() (int, error) {
+	defer logSlowQuery("RecomputeContentHashes", time.Now())
+
+	query := buildQuery(`UPDATE articles SET content_hash = ? WHERE id = ?;`)
+	return recomputeArticles(func(tx *sqlx.Tx, article *model.Article) error {
+		_, err := tx.Exec(query, model.ContentHash(article.Title, article.Body), article.ID)
+		return err
+	})
+}
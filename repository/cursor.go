@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"math"
+	"strconv"
+)
+
+// ErrInvalidCursor ...
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorUpperBound はカーソルの値を、ID の降順に取得する際の ID の上限に変換して返却します。
+// カーソルの値が 0 以下の場合は先頭のページとして int64 型の最大値を返却します。
+// int32 型の最大値では、ID がその値以上の記事が先頭のページから抜けてしまいます。
+func cursorUpperBound(cursor int64) int64 {
+	if cursor <= 0 {
+		return math.MaxInt64
+	}
+	return cursor
+}
+
+// EncodeCursor ...
+func EncodeCursor(id int) string {
+	// 記事 ID をそのまま公開しないように base64 でエンコードします。
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+// DecodeCursor ...
+func DecodeCursor(s string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(string(b))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
+
+// ArticleListByEncodedCursor ...
+func ArticleListByEncodedCursor(token string) ([]*model.Article, error) {
+	// トークンが空の場合は先頭のページを取得します。
+	if token == "" {
+		return ArticleListByCursor(0)
+	}
+
+	// 不正なトークンの場合は先頭のページを返さずにエラーにします。
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByEncodedCursor: %w", err))
+	}
+
+	return ArticleListByCursor(cursor)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEncodeDecodeCursor(t *testing.T) {
+	for _, id := range []int{1, 42, math.MaxInt32, math.MaxInt32 + 1} {
+		got, err := DecodeCursor(EncodeCursor(id))
+		if err != nil {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) error = %v", id, err)
+			continue
+		}
+		if got != id {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d", id, got)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, token := range []string{"!!!", EncodeCursor(0), EncodeCursor(-1), "YWJj"} {
+		if _, err := DecodeCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+}
+
+func TestCursorUpperBound(t *testing.T) {
+	tests := []struct {
+		cursor int64
+		want   int64
+	}{
+		{0, math.MaxInt64},
+		{-1, math.MaxInt64},
+		{1, 1},
+		{math.MaxInt32 + 1, math.MaxInt32 + 1},
+	}
+	for _, tt := range tests {
+		if got := cursorUpperBound(tt.cursor); got != tt.want {
+			t.Errorf("cursorUpperBound(%d) = %d, want %d", tt.cursor, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// denormalizedBatchSize は非正規化カラムを確認する記事を一度に読み込む件数です。
+const denormalizedBatchSize = 100
+
+// VerifyDenormalized ...
+func VerifyDenormalized() ([]int, error) {
+	defer logSlowQuery("VerifyDenormalized", time.Now())
+
+	// すべての記事をメモリに読み込まないよう、ID の順に一定件数ずつ読み込みます。
+	// ゴミ箱に入っている記事も、元に戻した際に正しい値で表示されるよう対象とします。
+	query := buildQuery(`SELECT id, title, body, body_format, word_count, content_hash, tags_cache,
+	` + tagsCacheExpr + ` AS expected_tags_cache
+	FROM articles
+	WHERE id > ?
+	ORDER BY id
+	LIMIT ?;`)
+
+	ids := []int{}
+	lastID := 0
+	for {
+		var rows []struct {
+			model.Article
+			ExpectedTagsCache string `db:"expected_tags_cache"`
+		}
+		if err := getDB().Select(&rows, query, lastID, denormalizedBatchSize); err != nil {
+			return nil, ClassifyError(fmt.Errorf("VerifyDenormalized: %w", err))
+		}
+		if len(rows) == 0 {
+			return ids, nil
+		}
+
+		// 本文とタグから求め直した値と、保存されている値を比較します。
+		for _, row := range rows {
+			expected := row.Article
+			setDerivedColumns(&expected)
+			if row.WordCount != expected.WordCount ||
+				row.ContentHash != expected.ContentHash ||
+				row.TagsCache != row.ExpectedTagsCache {
+				ids = append(ids, row.ID)
+			}
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// RepairDenormalized ...
+func RepairDenormalized(ids []int) error {
+	defer logSlowQuery("RepairDenormalized", time.Now())
+
+	// 対象の記事が指定されていない場合は何もしません。
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// 求め直している間に本文が変更されないよう、FOR UPDATE でロックしながら取得します。
+	q1, args, err := sqlx.In(buildQuery(`SELECT id, title, body, body_format FROM articles WHERE id IN(?) ORDER BY id FOR UPDATE;`), ids)
+	if err != nil {
+		return ClassifyError(fmt.Errorf("RepairDenormalized: %w", err))
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("RepairDenormalized: %w", err))
+	}
+
+	var articles []*model.Article
+	if err := tx.Select(&articles, q1, args...); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("RepairDenormalized: %w", err))
+	}
+
+	// 本文とタグから値を求め直して保存します。
+	// 記事の内容は変わらないため、更新日時は変更しません。
+	q2 := buildQuery(`UPDATE articles SET word_count = ?, content_hash = ? WHERE id = ?;`)
+	for _, article := range articles {
+		setDerivedColumns(article)
+		if _, err := tx.Exec(q2, article.WordCount, article.ContentHash, article.ID); err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("RepairDenormalized: %w", err))
+		}
+		if err := refreshTagCache(tx, article.ID); err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("RepairDenormalized: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("RepairDenormalized: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go-tech-blog/model"
+	"io"
+	"time"
+)
+
+// exportBatchSize は NDJSON の出力時にまとめてタグを取得し、書き出しを行う件数です。
+const exportBatchSize = 100
+
+// ExportArticlesNDJSON ...
+func ExportArticlesNDJSON(w io.Writer) error {
+	defer logSlowQuery("ExportArticlesNDJSON", time.Now())
+
+	// すべての記事をメモリに読み込まないよう、Queryx で一行ずつ読み込みます。
+	query := buildQuery(`SELECT ` + articleColumnsQualified + `, COALESCE(writers.name, '') AS writer_name
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	ORDER BY articles.id;`)
+
+	rows, err := getDB().Queryx(query)
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ExportArticlesNDJSON: %w", err))
+	}
+	defer rows.Close()
+
+	// エンコーダーは使い回し、一記事ごとに一行の JSON を書き出します。
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	// 一定件数ごとにタグをまとめて取得して書き出し、バッファをフラッシュします。
+	batch := make([]*model.Article, 0, exportBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := attachTags(batch); err != nil {
+			return err
+		}
+		for _, article := range batch {
+			if err := enc.Encode(article); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return bw.Flush()
+	}
+
+	for rows.Next() {
+		var article model.Article
+		if err := rows.StructScan(&article); err != nil {
+			return ClassifyError(fmt.Errorf("ExportArticlesNDJSON: %w", err))
+		}
+
+		batch = append(batch, &article)
+		if len(batch) == exportBatchSize {
+			if err := flush(); err != nil {
+				return ClassifyError(fmt.Errorf("ExportArticlesNDJSON: %w", err))
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return ClassifyError(fmt.Errorf("ExportArticlesNDJSON: %w", err))
+	}
+
+	if err := flush(); err != nil {
+		return ClassifyError(fmt.Errorf("ExportArticlesNDJSON: %w", err))
+	}
+	return nil
+}
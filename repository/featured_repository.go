@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+)
+
+// MaxFeaturedArticles はおすすめに設定できる記事の最大件数です。
+// 0 以下の場合は件数を制限しません。
+var MaxFeaturedArticles = 5
+
+// ErrFeaturedLimitReached ...
+var ErrFeaturedLimitReached = errors.New("featured article limit reached")
+
+// ArticleSetFeatured ...
+func ArticleSetFeatured(id int, featured bool) error {
+	defer logSlowQuery("ArticleSetFeatured", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetFeatured: %w", err))
+	}
+
+	// おすすめに設定する場合は、最大件数を超えないかを確認します。
+	// 同時に設定された場合に両方が件数を超えて設定されないよう、
+	// おすすめの記事の行をロックして数え、同じトランザクション内で更新します。
+	if featured && MaxFeaturedArticles > 0 {
+		var count int
+		q1 := buildQuery(`SELECT COUNT(*) FROM articles
+		WHERE featured = 1 AND deleted_at IS NULL AND id <> ?
+		FOR UPDATE;`)
+		if err := tx.Get(&count, q1, id); err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("ArticleSetFeatured: %w", err))
+		}
+		if count >= MaxFeaturedArticles {
+			tx.Rollback()
+			return ErrFeaturedLimitReached
+		}
+	}
+
+	query := buildQuery(`UPDATE articles SET featured = ? WHERE id = ?;`)
+	if _, err := tx.Exec(query, featured, id); err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSetFeatured: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetFeatured: %w", err))
+	}
+	return nil
+}
+
+// ArticleListFeatured ...
+func ArticleListFeatured(limit int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListFeatured", time.Now())
+
+	// 取得件数が 0 以下の場合は空のスライスを返却します。
+	if limit <= 0 {
+		return []*model.Article{}, nil
+	}
+
+	// ピックアップされている公開中の記事を、編集者が指定した表示順に取得します。
+	// 表示順が同じ場合は更新日時の新しい順にします。
+	// 件数が少ないためカーソルによるページングは行いません。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE featured = 1
+	AND noindex = 0
+	AND status = ?
+	AND deleted_at IS NULL
+	AND ` + hiddenTagFilter + `
+	ORDER BY featured_order, updated desc, id desc
+	LIMIT ?`)
+
+	articles := make([]*model.Article, 0, limit)
+	if err := getDB().Select(&articles, query, model.ArticleStatusPublished, limit); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListFeatured: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleSetFeaturedOrder ...
+func ArticleSetFeaturedOrder(id, order int) error {
+	defer logSlowQuery("ArticleSetFeaturedOrder", time.Now())
+
+	query := buildQuery(`UPDATE articles SET featured_order = ? WHERE id = ?;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetFeaturedOrder: %w", err))
+	}
+
+	if _, err := tx.Exec(query, order, id); err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSetFeaturedOrder: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetFeaturedOrder: %w", err))
+	}
+	return nil
+}
+
+// ArticleReorderFeatured ...
+func ArticleReorderFeatured(orderedIDs []int) error {
+	defer logSlowQuery("ArticleReorderFeatured", time.Now())
+
+	query := buildQuery(`UPDATE articles SET featured_order = ? WHERE id = ?;`)
+
+	// トランザクションを開始します。
+	// 途中で失敗した場合に表示順が中途半端にならないよう、すべての更新を一つのトランザクションで行います。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleReorderFeatured: %w", err))
+	}
+
+	// 並び替えた順に 1 から表示順を振り直します。
+	for i, id := range orderedIDs {
+		if _, err := tx.Exec(query, i+1, id); err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("ArticleReorderFeatured: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleReorderFeatured: %w", err))
+	}
+	return nil
+}
+
+// homepageFeaturedLimit はトップページの最初のページに表示するおすすめの記事の最大件数です。
+// 残りの件数は必ず新着の記事で埋めるため、1 ページの件数より少なくしています。
+const homepageFeaturedLimit = 5
+
+// ArticleListHomepage ...
+func ArticleListHomepage(cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListHomepage", time.Now())
+
+	// カーソルの値が 0 以下の場合は先頭のページとして、新着の記事は ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 最初のページでは、おすすめの記事を先頭に並べます。
+	articles := make([]*model.Article, 0, articlePageSize)
+	if cursor <= 0 {
+		featured, err := ArticleListFeatured(homepageFeaturedLimit)
+		if err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleListHomepage: %w", err))
+		}
+		articles = append(articles, featured...)
+	}
+
+	// おすすめの記事の後ろは、ArticleListByCursor() と同じ条件で新着の記事を ID の降順に並べます。
+	// 同じ記事が二度表示されないよう、新着の記事からはどのページでもおすすめの記事を除外します。
+	// 次のページのカーソルには最後の記事（新着の記事）の ID を指定します。
+	// ページを送る間におすすめが解除された記事は、以降のページに新着の記事として表示されることがあります。
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where("featured = 0").
+		Where(publicArticleFilter).
+		OrderBy("id desc").
+		Limit(articlePageSize - len(articles)).
+		Build()
+
+	recent := make([]*model.Article, 0, articlePageSize-len(articles))
+	if err := getDB().Select(&recent, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListHomepage: %w", err))
+	}
+
+	return append(articles, recent...), nil
+}
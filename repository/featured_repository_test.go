@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"go-tech-blog/model"
+	"testing"
+)
+
+func TestArticleListHomepage(t *testing.T) {
+	NewTestDB(t)
+
+	create := func(title, status string) *model.Article {
+		t.Helper()
+		article := &model.Article{Title: title, Body: "body", Status: status}
+		if _, err := ArticleCreate(article); err != nil {
+			t.Fatalf("ArticleCreate(%q): %v", title, err)
+		}
+		return article
+	}
+
+	recent := create("recent", model.ArticleStatusPublished)
+	featured := create("featured", model.ArticleStatusPublished)
+	create("draft", model.ArticleStatusDraft)
+	trashed := create("trashed", model.ArticleStatusPublished)
+
+	if err := ArticleSetFeatured(featured.ID, true); err != nil {
+		t.Fatalf("ArticleSetFeatured: %v", err)
+	}
+	if err := ArticleTrash(trashed.ID); err != nil {
+		t.Fatalf("ArticleTrash: %v", err)
+	}
+
+	articles, err := ArticleListHomepage(0)
+	if err != nil {
+		t.Fatalf("ArticleListHomepage: %v", err)
+	}
+
+	// おすすめの記事が先頭で、下書きとゴミ箱に入っている記事は含まれず、おすすめの記事は重複しません。
+	want := []int{featured.ID, recent.ID}
+	if len(articles) != len(want) {
+		t.Fatalf("ArticleListHomepage() returned %d articles, want %d", len(articles), len(want))
+	}
+	for i, article := range articles {
+		if article.ID != want[i] {
+			t.Errorf("articles[%d].ID = %d (%s), want %d", i, article.ID, article.Title, want[i])
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+)
+
+// WriterFollow ...
+func WriterFollow(followerID, writerID int) error {
+	defer logSlowQuery("WriterFollow", time.Now())
+
+	// 同じ筆者を何度フォローしても一件のみ記録します。
+	query := buildQuery(`INSERT IGNORE INTO follows (follower_id, writer_id, created) VALUES (?, ?, ?);`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("WriterFollow: %w", err))
+	}
+
+	if _, err := tx.Exec(query, followerID, writerID, timeNow()); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("WriterFollow: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("WriterFollow: %w", err))
+	}
+	return nil
+}
+
+// WriterUnfollow ...
+func WriterUnfollow(followerID, writerID int) error {
+	defer logSlowQuery("WriterUnfollow", time.Now())
+
+	// フォローしていない場合も成功として扱います。
+	query := buildQuery(`DELETE FROM follows WHERE follower_id = ? AND writer_id = ?;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("WriterUnfollow: %w", err))
+	}
+
+	if _, err := tx.Exec(query, followerID, writerID); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("WriterUnfollow: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("WriterUnfollow: %w", err))
+	}
+	return nil
+}
+
+// WriterFollowerCount ...
+func WriterFollowerCount(writerID int) (int, error) {
+	defer logSlowQuery("WriterFollowerCount", time.Now())
+
+	query := buildQuery(`SELECT COUNT(*) FROM follows WHERE writer_id = ?;`)
+
+	var count int
+	if err := getDB().Get(&count, query, writerID); err != nil {
+		return 0, ClassifyError(fmt.Errorf("WriterFollowerCount: %w", err))
+	}
+
+	return count, nil
+}
+
+// ArticleListFollowedFeed ...
+func ArticleListFollowedFeed(followerID, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListFollowedFeed", time.Now())
+
+	// フォローしている筆者の ID を取得します。
+	query := buildQuery(`SELECT writer_id FROM follows WHERE follower_id = ?;`)
+
+	var writerIDs []int
+	if err := getDB().Select(&writerIDs, query, followerID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListFollowedFeed: %w", err))
+	}
+
+	// フォローしている筆者の記事を取得します。
+	// 誰もフォローしていない場合は空の結果になります。
+	return ArticleListByWriterIDs(writerIDs, cursor)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+)
+
+// ErrIdempotencyKeyRequired ...
+var ErrIdempotencyKeyRequired = errors.New("idempotency key is required")
+
+// ArticleCreateIdempotent ...
+func ArticleCreateIdempotent(article *model.Article, idempotencyKey string) (*model.Article, error) {
+	defer logSlowQuery("ArticleCreateIdempotent", time.Now())
+
+	if idempotencyKey == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+
+	// 未指定の項目に初期値を設定し、保存する前に記事データの内容をチェックします。
+	if err := prepareArticleCreate(article); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+	}
+
+	// 同じ筆者・同じキーで作成済みの記事があるかをロックしながら確認します。
+	// キーは筆者ごとに管理するため、別の筆者が同じキーを使っても別の記事として作成されます。
+	var articleID int
+	q1 := buildQuery(`SELECT article_id FROM article_idempotency_keys
+	WHERE writer_id = ? AND idempotency_key = ? FOR UPDATE;`)
+	err = tx.Get(&articleID, q1, article.WriterID, idempotencyKey)
+
+	switch {
+	case err == nil:
+		// 作成済みの場合は新しく作成せずに、作成済みの記事を返却します。
+		var existing model.Article
+		if err := tx.Get(&existing, buildQuery(`SELECT `+articleColumns+` FROM articles WHERE id = ?;`), articleID); err != nil {
+			tx.Rollback()
+			return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+		}
+		return &existing, nil
+	case err != sql.ErrNoRows:
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+	}
+
+	// 記事を作成し、作成した記事の ID とキーを記録します。
+	if _, err := insertArticle(tx, article); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+	}
+
+	q2 := buildQuery(`INSERT INTO article_idempotency_keys (writer_id, idempotency_key, article_id, created)
+	VALUES (?, ?, ?, ?);`)
+	if _, err := tx.Exec(q2, article.WriterID, idempotencyKey, article.ID, article.Created); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateIdempotent: %w", err))
+	}
+
+	return article, nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+)
+
+// ArticleLike ...
+func ArticleLike(articleID int, visitorToken string) error {
+	defer logSlowQuery("ArticleLike", time.Now())
+
+	// 同じ訪問者が何度いいねしても一件のみ記録します。
+	query := buildQuery(`INSERT IGNORE INTO article_likes (article_id, visitor_token, created) VALUES (?, ?, ?);`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleLike: %w", err))
+	}
+
+	if _, err := tx.Exec(query, articleID, visitorToken, timeNow()); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleLike: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleLike: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"go-tech-blog/model"
+	"log"
+)
+
+// limitArticles は一覧の取得件数が上限を超えていないかを確認し、超えている場合は切り詰めます。
+// クエリの組み立て方を変更した際に LIMIT 句が抜けてしまっても、ページングの件数が変わらないようにします。
+// 上限を超えることは通常ないため、超えた場合はクエリの不具合としてログを出力します。
+func limitArticles(name string, articles []*model.Article, limit int) []*model.Article {
+	if len(articles) <= limit {
+		return articles
+	}
+
+	log.Printf("%s: returned %d rows, exceeding the limit of %d", name, len(articles), limit)
+	return articles[:limit]
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"go-tech-blog/model"
+	"testing"
+)
+
+func TestLimitArticles(t *testing.T) {
+	articles := make([]*model.Article, 5)
+	for i := range articles {
+		articles[i] = &model.Article{ID: i + 1}
+	}
+
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{10, 5},
+		{5, 5},
+		{3, 3},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		got := limitArticles("TestLimitArticles", articles, tt.limit)
+		if len(got) != tt.want {
+			t.Errorf("limitArticles(limit=%d) = %d articles, want %d", tt.limit, len(got), tt.want)
+			continue
+		}
+		// 切り詰める場合も、先頭からの並び順は変わりません。
+		for i, a := range got {
+			if a.ID != i+1 {
+				t.Errorf("limitArticles(limit=%d)[%d].ID = %d, want %d", tt.limit, i, a.ID, i+1)
+			}
+		}
+	}
+
+	if got := limitArticles("TestLimitArticles", nil, 10); got != nil {
+		t.Errorf("limitArticles(nil) = %v, want nil", got)
+	}
+}
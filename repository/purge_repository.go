@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ArticleBulkDelete ...
+func ArticleBulkDelete(ids []int, dryRun bool) ([]int, error) {
+	defer logSlowQuery("ArticleBulkDelete", time.Now())
+
+	// 対象の記事が指定されていない場合は何もしません。
+	if len(ids) == 0 {
+		return []int{}, nil
+	}
+
+	// 指定された記事のうち、存在する記事の ID を削除の対象とします。
+	q1, args, err := sqlx.In(buildQuery(`SELECT id FROM articles WHERE id IN(?) ORDER BY id FOR UPDATE;`), ids)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleBulkDelete: %w", err))
+	}
+
+	deleted, err := purgeArticles(q1, args, dryRun)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleBulkDelete: %w", err))
+	}
+	return deleted, nil
+}
+
+// ArticlePurgeDeletedBefore ...
+func ArticlePurgeDeletedBefore(before time.Time, dryRun bool) ([]int, error) {
+	defer logSlowQuery("ArticlePurgeDeletedBefore", time.Now())
+
+	// 指定した日時より前にゴミ箱に入れられた記事を削除の対象とします。
+	q1 := buildQuery(`SELECT id FROM articles
+	WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	ORDER BY id
+	FOR UPDATE;`)
+
+	deleted, err := purgeArticles(q1, []interface{}{before.UTC()}, dryRun)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticlePurgeDeletedBefore: %w", err))
+	}
+	return deleted, nil
+}
+
+// purgeArticles は query で取得した ID の記事を、紐づくデータと合わせて完全に削除します。
+// dryRun が true の場合は削除せずに、削除の対象となる記事の ID のみを返却します。
+func purgeArticles(query string, args []interface{}, dryRun bool) ([]int, error) {
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	// 削除するまでに対象の記事が変更されないよう、FOR UPDATE でロックしながら取得します。
+	ids := []int{}
+	if err := tx.Select(&ids, query, args...); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 確認のみの場合や対象の記事がない場合は、何も変更せずに対象の ID を返却します。
+	if dryRun || len(ids) == 0 {
+		tx.Rollback()
+		return ids, nil
+	}
+
+	if err := deleteArticleDependents(tx, ids); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	q, args, err := sqlx.In(buildQuery(`DELETE FROM articles WHERE id IN(?);`), ids)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := tx.Exec(q, args...); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// deleteArticleDependents は記事を削除する前に、記事を参照しているデータを削除します。
+// 外部キー制約があるため、参照している側のテーブルから削除する必要があります。
+func deleteArticleDependents(tx *sqlx.Tx, ids []int) error {
+	if err := deleteArticleTags(tx, ids); err != nil {
+		return err
+	}
+
+	queries := []string{
+		buildQuery(`DELETE FROM comments WHERE article_id IN(?);`),
+		buildQuery(`DELETE FROM article_likes WHERE article_id IN(?);`),
+		buildQuery(`DELETE FROM recent_views WHERE article_id IN(?);`),
+		buildQuery(`DELETE FROM article_revisions WHERE article_id IN(?);`),
+		buildQuery(`DELETE FROM article_idempotency_keys WHERE article_id IN(?);`),
+		buildQuery(`DELETE FROM autosaves WHERE article_id IN(?);`),
+		buildQuery(`DELETE FROM article_series WHERE article_id IN(?);`),
+		buildQuery(`DELETE FROM slug_aliases WHERE article_id IN(?);`),
+	}
+
+	for _, query := range queries {
+		q, args, err := sqlx.In(query, ids)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(q, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
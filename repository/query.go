@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"regexp"
+	"sync"
+)
+
+// TableNames ...
+type TableNames struct {
+	Articles         string
+	Writers          string
+	Tags             string
+	ArticlesTags     string
+	Comments         string
+	ArticleLikes     string
+	RecentViews      string
+	ArticleRevisions string
+	IdempotencyKeys  string
+	Autosaves        string
+	Series           string
+	ArticleSeries    string
+	SlugAliases      string
+	SearchLog        string
+	Follows          string
+	WriterTags       string
+}
+
+// DefaultTableNames ...
+var DefaultTableNames = TableNames{
+	Articles:         "articles",
+	Writers:          "writers",
+	Tags:             "tags",
+	ArticlesTags:     "articles_tags",
+	Comments:         "comments",
+	ArticleLikes:     "article_likes",
+	RecentViews:      "recent_views",
+	ArticleRevisions: "article_revisions",
+	IdempotencyKeys:  "article_idempotency_keys",
+	Autosaves:        "autosaves",
+	Series:           "series",
+	ArticleSeries:    "article_series",
+	SlugAliases:      "slug_aliases",
+	SearchLog:        "search_log",
+	Follows:          "follows",
+	WriterTags:       "writer_default_tags",
+}
+
+// tableRenames はデフォルトのテーブル名から設定したテーブル名への対応です。
+// 名前を変更していないテーブルは含めません。
+var (
+	tableRenames   map[string]string
+	tableRenamesMu sync.RWMutex
+	// 文字列リテラル中の値を書き換えないよう、シングルクォートで囲まれた部分にも一致させて、置き換えずに返却します。
+	tableNameRegex = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|\b(articles|writers|tags|articles_tags|comments|article_likes|recent_views|article_revisions|article_idempotency_keys|autosaves|series|article_series|slug_aliases|search_log|follows|writer_default_tags)\b`)
+)
+
+// SetTableNames ...
+func SetTableNames(t TableNames) {
+	renames := make(map[string]string)
+	for from, to := range map[string]string{
+		DefaultTableNames.Articles:         t.Articles,
+		DefaultTableNames.Writers:          t.Writers,
+		DefaultTableNames.Tags:             t.Tags,
+		DefaultTableNames.ArticlesTags:     t.ArticlesTags,
+		DefaultTableNames.Comments:         t.Comments,
+		DefaultTableNames.ArticleLikes:     t.ArticleLikes,
+		DefaultTableNames.RecentViews:      t.RecentViews,
+		DefaultTableNames.ArticleRevisions: t.ArticleRevisions,
+		DefaultTableNames.IdempotencyKeys:  t.IdempotencyKeys,
+		DefaultTableNames.Autosaves:        t.Autosaves,
+		DefaultTableNames.Series:           t.Series,
+		DefaultTableNames.ArticleSeries:    t.ArticleSeries,
+		DefaultTableNames.SlugAliases:      t.SlugAliases,
+		DefaultTableNames.SearchLog:        t.SearchLog,
+		DefaultTableNames.Follows:          t.Follows,
+		DefaultTableNames.WriterTags:       t.WriterTags,
+	} {
+		// 空の場合はデフォルトのテーブル名をそのまま利用します。
+		if to != "" && to != from {
+			renames[from] = to
+		}
+	}
+
+	tableRenamesMu.Lock()
+	defer tableRenamesMu.Unlock()
+
+	tableRenames = renames
+}
+
+// tableName はデフォルトのテーブル名を SetTableNames() で設定した名前に変換して返却します。
+// information_schema の検索条件など、クエリのパラメータとしてテーブル名を渡す場合に利用します。
+func tableName(name string) string {
+	tableRenamesMu.RLock()
+	defer tableRenamesMu.RUnlock()
+
+	if to, ok := tableRenames[name]; ok {
+		return to
+	}
+	return name
+}
+
+// buildQuery はクエリ文字列中のテーブル名を SetTableNames() で設定した名前に置き換えます。
+// リポジトリのクエリはすべてデフォルトのテーブル名で記述し、この関数を通して実行します。
+// テーブル名を変更していない場合はクエリ文字列をそのまま返却します。
+// 置き換えるのはテーブル名と一致する識別子のみで、カラム名の変更には対応しません。
+// シングルクォートで囲まれた文字列リテラルの中は置き換えないため、
+// 文字列としてテーブル名を渡す場合は tableName() で変換した値をパラメータにします。
+func buildQuery(query string) string {
+	tableRenamesMu.RLock()
+	defer tableRenamesMu.RUnlock()
+
+	if len(tableRenames) == 0 {
+		return query
+	}
+
+	return tableNameRegex.ReplaceAllStringFunc(query, func(name string) string {
+		if to, ok := tableRenames[name]; ok {
+			return to
+		}
+		return name
+	})
+}
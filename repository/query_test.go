@@ -0,0 +1,64 @@
+package repository
+
+import "testing"
+
+func TestBuildQuery(t *testing.T) {
+	renamed := DefaultTableNames
+	renamed.Articles = "blog_articles"
+	renamed.Tags = "blog_tags"
+	SetTableNames(renamed)
+	defer SetTableNames(DefaultTableNames)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "table names",
+			query: "SELECT articles.id FROM articles INNER JOIN articles_tags ON articles_tags.article_id = articles.id",
+			want:  "SELECT blog_articles.id FROM blog_articles INNER JOIN articles_tags ON articles_tags.article_id = blog_articles.id",
+		},
+		{
+			name:  "columns containing table names",
+			query: "SELECT tags_cache FROM articles",
+			want:  "SELECT tags_cache FROM blog_articles",
+		},
+		{
+			name:  "string literals",
+			query: "SELECT id FROM tags WHERE name = 'articles' OR name = 'it\\'s tags'",
+			want:  "SELECT id FROM blog_tags WHERE name = 'articles' OR name = 'it\\'s tags'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildQuery(tt.query); got != tt.want {
+				t.Errorf("buildQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildQueryDefault(t *testing.T) {
+	SetTableNames(DefaultTableNames)
+
+	query := "SELECT * FROM articles WHERE title = 'tags'"
+	if got := buildQuery(query); got != query {
+		t.Errorf("buildQuery() = %q, want %q", got, query)
+	}
+}
+
+func TestTableName(t *testing.T) {
+	renamed := DefaultTableNames
+	renamed.Articles = "blog_articles"
+	SetTableNames(renamed)
+	defer SetTableNames(DefaultTableNames)
+
+	if got := tableName("articles"); got != "blog_articles" {
+		t.Errorf("tableName(articles) = %q, want blog_articles", got)
+	}
+	if got := tableName("writers"); got != "writers" {
+		t.Errorf("tableName(writers) = %q, want writers", got)
+	}
+}
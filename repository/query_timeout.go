@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// queryTimeout は呼び出し元のコンテキストに期限が設定されていない場合に、クエリに設定する期限です。
+// 0 以下の場合は期限を設定しません。
+var queryTimeout time.Duration
+
+// SetQueryTimeout ...
+func SetQueryTimeout(timeout time.Duration) {
+	queryTimeout = timeout
+}
+
+// withQueryTimeout は呼び出し元のコンテキストに期限が設定されていない場合に、既定の期限を設定します。
+// 呼び出し元が期限を設定している場合は、その期限を優先してそのまま返却します。
+// 返却した関数はクエリの実行後に必ず呼び出してください。
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, queryTimeout)
+}
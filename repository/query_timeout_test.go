@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	defer SetQueryTimeout(0)
+
+	t.Run("default timeout", func(t *testing.T) {
+		SetQueryTimeout(time.Minute)
+
+		ctx, cancel := withQueryTimeout(context.Background())
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Fatal("deadline is not set")
+		}
+		if d := time.Until(deadline); d <= 0 || d > time.Minute {
+			t.Errorf("deadline in %v, want within 1m", d)
+		}
+	})
+
+	t.Run("caller deadline", func(t *testing.T) {
+		SetQueryTimeout(time.Minute)
+
+		want := time.Now().Add(time.Hour)
+		parent, parentCancel := context.WithDeadline(context.Background(), want)
+		defer parentCancel()
+
+		ctx, cancel := withQueryTimeout(parent)
+		defer cancel()
+
+		if deadline, _ := ctx.Deadline(); !deadline.Equal(want) {
+			t.Errorf("deadline = %v, want %v", deadline, want)
+		}
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		SetQueryTimeout(0)
+
+		ctx, cancel := withQueryTimeout(context.Background())
+		defer cancel()
+
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("deadline is set")
+		}
+	})
+}
+
+func TestArticleListByCursorContextCancelled(t *testing.T) {
+	NewTestDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ArticleListByCursorContext(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("ArticleListByCursorContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestArticleListByCursorContextTimeout(t *testing.T) {
+	NewTestDB(t)
+	defer SetQueryTimeout(0)
+
+	// 既定の期限を過ぎたコンテキストでは、クエリを実行せずに中断されます。
+	SetQueryTimeout(time.Nanosecond)
+	if _, err := ArticleListByCursorContext(context.Background(), 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ArticleListByCursorContext() error = %v, want context.DeadlineExceeded", err)
+	}
+}
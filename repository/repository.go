@@ -1,12 +1,98 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/jmoiron/sqlx"
 )
 
-var db *sqlx.DB
+// db はリポジトリが利用する DB のハンドルです。
+// サーバーの起動中やテストでの差し替え時に競合しないよう、必ず getDB() を通して参照します。
+var (
+	db   *sqlx.DB
+	dbMu sync.RWMutex
+)
+
+// Init ...
+func Init(d *sqlx.DB) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
 
-// SetDB ...
-func SetDB(d *sqlx.DB) {
 	db = d
+
+	// Close() した後に設定し直した場合も利用できるようにします。
+	atomic.StoreInt32(&closing, 0)
+
+	// 全文検索用のインデックスが利用できるかを確認しておきます。
+	setFulltextAvailable(detectFulltext(d))
+
+	// DB の再起動後も接続を維持できるよう、定期的に ping を送ります。
+	startPinger(d)
+}
+
+// getDB はロックを取得して DB のハンドルを返却します。
+func getDB() *sqlx.DB {
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+
+	return db
+}
+
+// SerializableTx はトランザクションの分離レベルを SERIALIZABLE にするオプションです。
+// 同時に書き込みが行われると結果が壊れるような重要な更新処理で WithTransaction() に渡します。
+var SerializableTx = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+// WithTransaction ...
+func WithTransaction(fn func(tx *sqlx.Tx) error, opts ...*sql.TxOptions) error {
+	// シャットダウン中の場合はトランザクションを開始しません。
+	if isClosing() {
+		return ClassifyError(ErrShuttingDown)
+	}
+
+	// オプションが指定されていない場合はドライバーのデフォルトの分離レベルを利用します。
+	var opt *sql.TxOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	// コミットする前に接続が切れた場合は、トランザクションの内容は反映されていません。
+	// 再接続を待ってから一度だけやり直します。
+	err, committing := runTransaction(fn, opt)
+	if !committing && isBadConnection(err) {
+		if werr := waitForConnection(); werr != nil {
+			return err
+		}
+		err, _ = runTransaction(fn, opt)
+	}
+	return ClassifyError(err)
+}
+
+// runTransaction はトランザクション内で fn を実行してコミットします。
+// コミットの処理中に発生したエラーの場合は、反映されたかどうか分からないため committing を true にします。
+func runTransaction(fn func(tx *sqlx.Tx) error, opt *sql.TxOptions) (err error, committing bool) {
+	// トランザクションを開始します。
+	tx, err := getDB().BeginTxx(context.Background(), opt)
+	if err != nil {
+		return err, false
+	}
+
+	// 引数で渡された処理でエラーが発生した場合はロールバックします。
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err, false
+	}
+
+	// エラーがない場合はコミットします。
+	return tx.Commit(), true
+}
+
+// timeNow は DB に保存する現在日時を返却します。
+// datetime 型のカラムは秒までしか保持しないため、UTC に揃えて秒未満を切り捨てます。
+// こうすることで保存前の値と DB から読み直した値が一致するようになります。
+func timeNow() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
 }
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"strings"
+	"time"
+)
+
+// ErrRevisionNotFound ...
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// ArticleRevisionCreate ...
+func ArticleRevisionCreate(articleID int) (*model.ArticleRevision, error) {
+	defer logSlowQuery("ArticleRevisionCreate", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleRevisionCreate: %w", err))
+	}
+
+	// 現在の記事の内容をリビジョンとして保存します。
+	// リビジョン番号は記事ごとに 1 から順に採番します。
+	q1 := buildQuery(`INSERT INTO article_revisions (article_id, revision, title, body, created)
+	SELECT
+		articles.id,
+		COALESCE((SELECT MAX(revision) FROM article_revisions WHERE article_id = articles.id), 0) + 1,
+		articles.title,
+		articles.body,
+		?
+	FROM articles
+	WHERE articles.id = ?;`)
+	res, err := tx.Exec(q1, timeNow(), articleID)
+	if err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleRevisionCreate: %w", err))
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleRevisionCreate: %w", err))
+	}
+
+	// 記事が存在しない場合は一行も追加されないため、ID が採番されていません。
+	var revision model.ArticleRevision
+	if err := tx.Get(&revision, buildQuery(`SELECT * FROM article_revisions WHERE id = ?;`), id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleRevisionCreate: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleRevisionCreate: %w", err))
+	}
+
+	return &revision, nil
+}
+
+// ArticleRevisionGet ...
+func ArticleRevisionGet(articleID, revision int) (*model.ArticleRevision, error) {
+	defer logSlowQuery("ArticleRevisionGet", time.Now())
+
+	query := buildQuery(`SELECT * FROM article_revisions WHERE article_id = ? AND revision = ?;`)
+
+	var rev model.ArticleRevision
+	if err := getDB().Get(&rev, query, articleID, revision); err != nil {
+		// 記事に属していないリビジョンを指定した場合もエラーになります。
+		if err == sql.ErrNoRows {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleRevisionGet: %w", err))
+	}
+
+	return &rev, nil
+}
+
+// ArticleRevisionDiff ...
+func ArticleRevisionDiff(articleID, fromRev, toRev int) (string, error) {
+	from, err := ArticleRevisionGet(articleID, fromRev)
+	if err != nil {
+		return "", ClassifyError(fmt.Errorf("ArticleRevisionDiff: %w", err))
+	}
+
+	to, err := ArticleRevisionGet(articleID, toRev)
+	if err != nil {
+		return "", ClassifyError(fmt.Errorf("ArticleRevisionDiff: %w", err))
+	}
+
+	// 本文を行単位で比較して unified diff 形式の文字列を生成します。
+	a := strings.Split(strings.ReplaceAll(from.Body, "\r\n", "\n"), "\n")
+	b := strings.Split(strings.ReplaceAll(to.Body, "\r\n", "\n"), "\n")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- revision %d\n", from.Revision)
+	fmt.Fprintf(&sb, "+++ revision %d\n", to.Revision)
+	for _, line := range diffLines(a, b) {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+// diffLines は二つの行の一覧を最長共通部分列（LCS）で比較し、
+// 共通の行には " "、削除された行には "-"、追加された行には "+" を先頭に付けて返却します。
+func diffLines(a, b []string) []string {
+	// lcs[i][j] には a[i:] と b[j:] の最長共通部分列の長さを格納します。
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+
+	return lines
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"database/sql"
+	"go-tech-blog/model"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// BodyPolicy は HTML 形式の本文を保存する前に、危険なタグや属性を取り除くポリシーです。
+// 許可するタグを変更したい場合は、サーバーの起動時に差し替えます。
+var BodyPolicy = bluemonday.UGCPolicy()
+
+// sanitizeBody は HTML 形式の本文から危険なタグを取り除きます。
+// Markdown 形式の本文は表示する際に変換・サニタイズするため、そのまま保存します。
+func sanitizeBody(article *model.Article) {
+	if article.BodyFormat == model.BodyFormatHTML {
+		article.Body = BodyPolicy.Sanitize(article.Body)
+	}
+}
+
+// sanitizeBodyForUpdate は更新する記事の本文をサニタイズします。
+// 本文の形式が指定されていない場合は保存されている形式のまま更新されるため、現在の形式を構造体に設定して判定します。
+func sanitizeBodyForUpdate(article *model.Article) error {
+	if article.BodyFormat != "" {
+		sanitizeBody(article)
+		return nil
+	}
+
+	var format string
+	err := getDB().Get(&format, buildQuery(`SELECT body_format FROM articles WHERE id = ?;`), article.ID)
+	if err == sql.ErrNoRows {
+		// 記事が存在しない場合は更新されないため、ここでは何もしません。
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	article.BodyFormat = format
+	sanitizeBody(article)
+	return nil
+}
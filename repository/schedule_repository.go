@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxZoneOffset はタイムゾーンの UTC からの時差の最大値です（UTC+14:00）。
+// 予約日時はタイムゾーンなしで保存しているため、候補を絞り込む際の余裕として利用します。
+const maxZoneOffset = 14 * time.Hour
+
+// ArticleSchedulePublish ...
+func ArticleSchedulePublish(id int, publishAt time.Time) error {
+	defer logSlowQuery("ArticleSchedulePublish", time.Now())
+
+	// 予約日時は筆者のタイムゾーンでの日時（「9 時」など）として扱うため、
+	// 引数の日時のタイムゾーンは無視して、年月日と時刻のみを保存します。
+	wall := time.Date(publishAt.Year(), publishAt.Month(), publishAt.Day(),
+		publishAt.Hour(), publishAt.Minute(), publishAt.Second(), 0, time.UTC)
+
+	query := buildQuery(`UPDATE articles
+	SET publish_at = ?, updated = ?
+	WHERE id = ? AND status = ? AND deleted_at IS NULL;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSchedulePublish: %w", err))
+	}
+
+	res, err := tx.Exec(query, wall, timeNow(), id, model.ArticleStatusDraft)
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSchedulePublish: %w", err))
+	}
+
+	// 公開済みやゴミ箱に入っている記事は予約できません。
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+		return ErrArticleNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSchedulePublish: %w", err))
+	}
+	return nil
+}
+
+// ArticlePublishDue ...
+func ArticlePublishDue(now time.Time) (int, error) {
+	defer logSlowQuery("ArticlePublishDue", time.Now())
+
+	// 予約日時を過ぎた可能性がある下書きを、筆者のタイムゾーンと合わせて取得します。
+	// どのタイムゾーンでも公開日時になっていない記事は SQL の時点で除外します。
+	q1 := buildQuery(`SELECT
+		articles.id AS id,
+		articles.publish_at AS publish_at,
+		COALESCE(writers.timezone, '') AS timezone
+	FROM articles
+	LEFT JOIN writers ON writers.id = articles.writer_id
+	WHERE articles.status = ?
+	AND articles.publish_at IS NOT NULL
+	AND articles.publish_at <= ?
+	AND articles.deleted_at IS NULL;`)
+
+	var candidates []struct {
+		ID        int       `db:"id"`
+		PublishAt time.Time `db:"publish_at"`
+		Timezone  string    `db:"timezone"`
+	}
+	limit := now.UTC().Add(maxZoneOffset)
+	if err := getDB().Select(&candidates, q1, model.ArticleStatusDraft, limit); err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticlePublishDue: %w", err))
+	}
+
+	// 予約日時を筆者のタイムゾーンの日時として UTC に変換し、現在日時と比較します。
+	// タイムゾーンが設定されていない筆者は UTC として扱います。
+	ids := make([]int, 0, len(candidates))
+	for _, c := range candidates {
+		loc := (&model.Writer{Timezone: c.Timezone}).Location()
+		due := time.Date(c.PublishAt.Year(), c.PublishAt.Month(), c.PublishAt.Day(),
+			c.PublishAt.Hour(), c.PublishAt.Minute(), c.PublishAt.Second(), 0, loc)
+		if !due.After(now) {
+			ids = append(ids, c.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// 公開する記事のステータスを変更し、予約日時を削除します。
+	// 取得してから更新するまでに公開や予約の取り消しが行われた記事は対象外です。
+	q2 := buildQuery(`UPDATE articles
+	SET status = ?, publish_at = NULL, updated = ?, published_at = ` + publishedAtOnPublish + `
+	WHERE id IN(?) AND status = ? AND publish_at IS NOT NULL;`)
+
+	updated := timeNow()
+	q3, args, err := sqlx.In(q2, model.ArticleStatusPublished, updated, model.ArticleStatusPublished, updated, ids, model.ArticleStatusDraft)
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticlePublishDue: %w", err))
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticlePublishDue: %w", err))
+	}
+
+	res, err := tx.Exec(q3, args...)
+	if err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("ArticlePublishDue: %w", err))
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("ArticlePublishDue: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticlePublishDue: %w", err))
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 検索結果の順位です。タイトルか本文に一致した記事をタグのみ一致した記事より上位にします。
+const (
+	searchRankDirect = 0
+	searchRankTag    = 1
+)
+
+// likeEscaper は LIKE 句のワイルドカードとエスケープ文字をエスケープします。
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern はキーワードを部分一致検索用の LIKE パターンに変換します。
+func likePattern(keyword string) string {
+	return "%" + likeEscaper.Replace(keyword) + "%"
+}
+
+// searchKeywordFilter はタイトル・本文・タグ名のいずれかがキーワードに一致する記事に絞り込む条件です。
+// :keyword には likePattern() でエスケープしたパターンを指定します。
+// 検索結果の一覧と件数で同じ条件になるよう、ArticleSearch() と ArticleSearchCount() で共有します。
+const searchKeywordFilter = `(articles.title LIKE :keyword
+	OR articles.body LIKE :keyword
+	OR EXISTS (
+		SELECT 1 FROM articles_tags AS at
+		INNER JOIN tags ON tags.id = at.tag_id
+		WHERE at.article_id = articles.id AND tags.name LIKE :keyword
+	))`
+
+// ArticleSearch ...
+func ArticleSearch(keyword string, cursor model.SearchCursor) ([]*model.SearchResult, error) {
+	defer logSlowQuery("ArticleSearch", time.Now())
+
+	// 前後の空白を取り除いてキーワードが空になる場合は、
+	// すべての行を走査する LIKE 検索は行わず、通常の記事一覧を返却します。
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		articles, err := ArticleListByCursor(cursor.ID)
+		if err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleSearch: %w", err))
+		}
+
+		results := make([]*model.SearchResult, len(articles))
+		for i, article := range articles {
+			results[i] = &model.SearchResult{Article: article, Rank: searchRankDirect}
+		}
+		return results, nil
+	}
+
+	// カーソルの ID が 0 以下の場合は先頭のページとして、ID の上限を設けずに取得します。
+	// ページを送るたびに同じキーワードが記録されないよう、検索の記録は先頭のページを取得した場合のみ行います。
+	firstPage := cursor.ID <= 0
+	if firstPage {
+		cursor.Rank = searchRankDirect
+	}
+
+	// タイトル・本文に一致する記事と、タグ名に一致する記事をまとめて取得します。
+	// タグの条件は EXISTS で判定するため、複数のタグに一致しても記事は重複しません。
+	// 下書きやゴミ箱に入っている記事の本文が検索できないよう、公開中の一覧と同じ記事のみを対象にします。
+	// 順位と ID の組み合わせをカーソルにして、順位の昇順・ID の降順に 10 件ずつ取得します。
+	// オフセットではなく前のページの最後の値より後ろの行を取得するため、
+	// ページの取得中に記事が追加されても、次のページで記事が重複したり抜けたりしません。
+	q := buildQuery(`SELECT * FROM (
+		SELECT ` + articleColumnsQualified + `,
+			CASE WHEN articles.title LIKE :keyword OR articles.body LIKE :keyword
+				THEN :rank_direct ELSE :rank_tag END AS search_rank
+		FROM articles
+		WHERE ` + publicArticleFilter + `
+		AND ` + searchKeywordFilter + `
+	) AS results
+	WHERE search_rank > :cursor_rank
+	OR (search_rank = :cursor_rank AND id < :cursor_id)
+	ORDER BY search_rank, id desc
+	LIMIT 10`)
+
+	// 同じパラメータを複数箇所で使うため、名前付きパラメータを ? に展開します。
+	query, args, err := sqlx.Named(q, map[string]interface{}{
+		"keyword":     likePattern(keyword),
+		"rank_direct": searchRankDirect,
+		"rank_tag":    searchRankTag,
+		"cursor_rank": cursor.Rank,
+		"cursor_id":   cursorUpperBound(int64(cursor.ID)),
+	})
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleSearch: %w", err))
+	}
+
+	db := getDB()
+	results := make([]*model.SearchResult, 0, 10)
+	if err := db.Select(&results, db.Rebind(query), args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleSearch: %w", err))
+	}
+
+	if firstPage {
+		go logSearch(keyword, len(results))
+	}
+
+	return results, nil
+}
+
+// searchLogKeywordLength は記録するキーワードの最大文字数です。
+const searchLogKeywordLength = 255
+
+// logSearch は検索されたキーワードと検索結果の件数を記録します。
+// 検索のレスポンスを待たせないよう別の goroutine で実行し、記録に失敗しても検索はエラーにしません。
+func logSearch(keyword string, resultCount int) {
+	// カラムの長さを超えるキーワードは切り詰めて記録します。
+	if r := []rune(keyword); len(r) > searchLogKeywordLength {
+		keyword = string(r[:searchLogKeywordLength])
+	}
+
+	query := buildQuery(`INSERT INTO search_log (keyword, result_count, created) VALUES (?, ?, ?);`)
+	if _, err := getDB().Exec(query, keyword, resultCount, timeNow()); err != nil {
+		log.Printf("failed to log search keyword: %v", err)
+	}
+}
+
+// PopularSearches ...
+func PopularSearches(limit int) ([]*model.PopularSearch, error) {
+	defer logSlowQuery("PopularSearches", time.Now())
+
+	// 取得件数が 0 以下の場合は空のスライスを返却します。
+	if limit <= 0 {
+		return []*model.PopularSearch{}, nil
+	}
+
+	// 検索された回数の多い順にキーワードを取得します。
+	// 回数が同じ場合はキーワードの順にして、取得するたびに順番が変わらないようにします。
+	query := buildQuery(`SELECT keyword, COUNT(*) AS count
+	FROM search_log
+	GROUP BY keyword
+	ORDER BY count desc, keyword
+	LIMIT ?`)
+
+	searches := make([]*model.PopularSearch, 0, limit)
+	if err := getDB().Select(&searches, query, limit); err != nil {
+		return nil, ClassifyError(fmt.Errorf("PopularSearches: %w", err))
+	}
+
+	return searches, nil
+}
+
+// fulltextAvailable は articles テーブルに全文検索用のインデックスがあるかどうかです。
+// Init() で DB のハンドルを設定する際に判定します。
+// 検索中に Init() で DB のハンドルが差し替えられても競合しないよう、atomic で読み書きします。
+var fulltextAvailable int32
+
+// isFulltextAvailable は全文検索用のインデックスが利用できるかを返却します。
+func isFulltextAvailable() bool {
+	return atomic.LoadInt32(&fulltextAvailable) == 1
+}
+
+// setFulltextAvailable は全文検索用のインデックスが利用できるかを設定します。
+func setFulltextAvailable(available bool) {
+	var v int32
+	if available {
+		v = 1
+	}
+	atomic.StoreInt32(&fulltextAvailable, v)
+}
+
+// detectFulltext は articles テーブルに FULLTEXT インデックスがあるかを確認します。
+func detectFulltext(d *sqlx.DB) bool {
+	if d == nil {
+		return false
+	}
+
+	query := buildQuery(`SELECT COUNT(*)
+	FROM information_schema.STATISTICS
+	WHERE TABLE_SCHEMA = DATABASE()
+	AND TABLE_NAME = ?
+	AND INDEX_TYPE = 'FULLTEXT';`)
+
+	var count int
+	if err := d.Get(&count, query, tableName("articles")); err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// ArticleSearchFuzzy ...
+func ArticleSearchFuzzy(keyword string, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleSearchFuzzy", time.Now())
+
+	// 関連度の順に並べるため、ID ではなく何件目から取得するか（オフセット）をカーソルとします。
+	if cursor < 0 {
+		cursor = 0
+	}
+
+	articles := make([]*model.Article, 0, 10)
+
+	// 全文検索用のインデックスがない場合は LIKE による部分一致検索を行います。
+	// どちらの場合も、公開中の一覧と同じ記事のみを対象にします。
+	if !isFulltextAvailable() {
+		query := buildQuery(`SELECT ` + articleColumns + `
+		FROM articles
+		WHERE (title LIKE ? OR body LIKE ?)
+		AND ` + publicArticleFilter + `
+		ORDER BY id desc
+		LIMIT 10 OFFSET ?`)
+
+		pattern := likePattern(keyword)
+		if err := getDB().Select(&articles, query, pattern, pattern, cursor); err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleSearchFuzzy: %w", err))
+		}
+		return articles, nil
+	}
+
+	// MATCH ... AGAINST で全文検索を行い、関連度の高い順に 10 件取得します。
+	// ngram パーサーを利用しているため、表記の一部が異なるキーワードでもヒットしやすくなります。
+	query := buildQuery(`SELECT ` + articleColumnsQualified + `
+	FROM articles
+	WHERE MATCH(title, body) AGAINST(? IN NATURAL LANGUAGE MODE)
+	AND ` + publicArticleFilter + `
+	ORDER BY MATCH(title, body) AGAINST(? IN NATURAL LANGUAGE MODE) desc, id desc
+	LIMIT 10 OFFSET ?`)
+
+	if err := getDB().Select(&articles, query, keyword, keyword, cursor); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleSearchFuzzy: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ArticleTitleList ...
+func ArticleTitleList(keyword string, limit int) ([]model.ArticleRef, error) {
+	defer logSlowQuery("ArticleTitleList", time.Now())
+
+	// 取得件数が 0 以下の場合は空のスライスを返却します。
+	if limit <= 0 {
+		return []model.ArticleRef{}, nil
+	}
+
+	// 記事を選択する画面の候補として、本文は読み込まずに ID とタイトルだけを取得します。
+	// 下書きも選択できるよう、ゴミ箱に入っている記事以外はステータスに関わらず対象にします。
+	// キーワードが空の場合は新しい記事から順に返却します。
+	b := newSelectBuilder("id, title", "articles").
+		Where("deleted_at IS NULL")
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		b = b.Where("title LIKE ?", likePattern(keyword))
+	}
+	query, args := b.OrderBy("id desc").Limit(limit).Build()
+
+	refs := make([]model.ArticleRef, 0, limit)
+	if err := getDB().Select(&refs, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleTitleList: %w", err))
+	}
+
+	return refs, nil
+}
+
+// ErrStopIteration ...
+var ErrStopIteration = errors.New("stop iteration")
+
+// ArticleSearchIterate ...
+func ArticleSearchIterate(keyword string, fn func(*model.Article) error) error {
+	defer logSlowQuery("ArticleSearchIterate", time.Now())
+
+	// ArticleSearch() と同じく、タイトル・本文・タグ名のいずれかに一致する記事を対象にします。
+	// 管理画面の一括編集で利用するため、下書きも含めてゴミ箱に入っている記事以外をすべて ID の順に取得します。
+	// キーワードが空の場合はすべての記事を対象にします。
+	b := newSelectBuilder(articleColumns, "articles").
+		Where("deleted_at IS NULL")
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		pattern := likePattern(keyword)
+		b = b.Where(`(title LIKE ? OR body LIKE ? OR EXISTS (
+			SELECT 1 FROM articles_tags AS at
+			INNER JOIN tags ON tags.id = at.tag_id
+			WHERE at.article_id = articles.id AND tags.name LIKE ?
+		))`, pattern, pattern, pattern)
+	}
+	query, args := b.OrderBy("id").Build()
+
+	// すべての記事をメモリに読み込まないよう、Queryx で一行ずつ読み込みます。
+	// 読み込みが終わるまで接続を一つ使い続けるため、fn の中で時間のかかる処理を行う場合は注意してください。
+	rows, err := getDB().Queryx(query, args...)
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSearchIterate: %w", err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var article model.Article
+		if err := rows.StructScan(&article); err != nil {
+			return ClassifyError(fmt.Errorf("ArticleSearchIterate: %w", err))
+		}
+
+		// fn が ErrStopIteration を返却した場合は、途中で終了してエラーにしません。
+		if err := fn(&article); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSearchIterate: %w", err))
+	}
+
+	return nil
+}
+
+// ArticleSearchCount ...
+func ArticleSearchCount(keyword string) (int, error) {
+	defer logSlowQuery("ArticleSearchCount", time.Now())
+
+	// 検索結果の一覧と件数が一致するよう、ArticleSearch() と同じ条件で行を取得せずに件数のみを数えます。
+	// キーワードが空の場合は、ArticleSearch() が返却する通常の一覧と同じ公開中の記事の件数になります。
+	q := `SELECT COUNT(*) FROM articles WHERE ` + publicArticleFilter
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		q += ` AND ` + searchKeywordFilter
+	}
+	query, args, err := sqlx.Named(buildQuery(q), map[string]interface{}{
+		"keyword": likePattern(keyword),
+	})
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleSearchCount: %w", err))
+	}
+
+	db := getDB()
+	var count int
+	if err := db.Get(&count, db.Rebind(query), args...); err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleSearchCount: %w", err))
+	}
+
+	return count, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"testing"
+)
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    string
+	}{
+		{"go", `%go%`},
+		{"100%", `%100\%%`},
+		{"snake_case", `%snake\_case%`},
+		{`C:\path`, `%C:\\path%`},
+		{"", `%%`},
+	}
+	for _, tt := range tests {
+		if got := likePattern(tt.keyword); got != tt.want {
+			t.Errorf("likePattern(%q) = %q, want %q", tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestArticleSearchPagingWithInsert(t *testing.T) {
+	NewTestDB(t)
+
+	create := func(title string) *model.Article {
+		t.Helper()
+		article := &model.Article{Title: title, Body: "body"}
+		if _, err := ArticleCreate(article); err != nil {
+			t.Fatalf("ArticleCreate(%q): %v", title, err)
+		}
+		return article
+	}
+	for i := 0; i < 12; i++ {
+		create(fmt.Sprintf("golang %d", i))
+	}
+
+	page1, err := ArticleSearch("golang", model.SearchCursor{})
+	if err != nil {
+		t.Fatalf("ArticleSearch: %v", err)
+	}
+	if len(page1) != 10 {
+		t.Fatalf("page 1 = %d results, want 10", len(page1))
+	}
+
+	// ページを取得する間に、検索に一致する記事が追加された場合を再現します。
+	added := create("golang new")
+
+	page2, err := ArticleSearch("golang", page1[len(page1)-1].Cursor())
+	if err != nil {
+		t.Fatalf("ArticleSearch: %v", err)
+	}
+
+	// 2 ページ目には 1 ページ目の記事が重複せず、残りの 2 件のみが含まれます。
+	seen := map[int]bool{}
+	for _, r := range page1 {
+		seen[r.ID] = true
+	}
+	for _, r := range page2 {
+		if seen[r.ID] {
+			t.Errorf("article %d appears on both pages", r.ID)
+		}
+		if r.ID == added.ID {
+			t.Errorf("article added after page 1 appears on page 2")
+		}
+		seen[r.ID] = true
+	}
+	if len(page2) != 2 {
+		t.Errorf("page 2 = %d results, want 2", len(page2))
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+)
+
+// selectBuilder は SELECT 文を取得カラム、条件、並び順、件数に分けて組み立てます。
+// 一覧の取得処理で条件を付け外ししたり、他のテーブルの SELECT 文と UNION で
+// 結合したりできるよう、クエリ文字列を部品ごとに保持します。
+type selectBuilder struct {
+	columns string
+	from    string
+	where   []string
+	args    []interface{}
+	orderBy string
+	limit   int
+}
+
+// newSelectBuilder は取得するカラムとテーブルを指定して selectBuilder を生成します。
+func newSelectBuilder(columns, from string) *selectBuilder {
+	return &selectBuilder{columns: columns, from: from}
+}
+
+// Where は条件を追加します。複数指定した場合は AND で結合します。
+// 条件中の ? には args の値が順に bind されます。
+func (b *selectBuilder) Where(cond string, args ...interface{}) *selectBuilder {
+	b.where = append(b.where, cond)
+	b.args = append(b.args, args...)
+	return b
+}
+
+// OrderBy は並び順を設定します。
+func (b *selectBuilder) OrderBy(orderBy string) *selectBuilder {
+	b.orderBy = orderBy
+	return b
+}
+
+// Limit は取得する件数を設定します。0 以下の場合は件数を制限しません。
+func (b *selectBuilder) Limit(limit int) *selectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Build はクエリ文字列と bind するパラメータを返却します。
+// テーブル名は buildQuery() を通して設定した名前に置き換えます。
+func (b *selectBuilder) Build() (string, []interface{}) {
+	var sb strings.Builder
+
+	sb.WriteString("SELECT ")
+	sb.WriteString(b.columns)
+	sb.WriteString("\n\tFROM ")
+	sb.WriteString(b.from)
+
+	if len(b.where) > 0 {
+		sb.WriteString("\n\tWHERE ")
+		sb.WriteString(strings.Join(b.where, " AND "))
+	}
+
+	if b.orderBy != "" {
+		sb.WriteString("\n\tORDER BY ")
+		sb.WriteString(b.orderBy)
+	}
+
+	if b.limit > 0 {
+		sb.WriteString("\n\tLIMIT ")
+		sb.WriteString(strconv.Itoa(b.limit))
+	}
+
+	return buildQuery(sb.String()), b.args
+}
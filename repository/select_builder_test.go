@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectBuilder(t *testing.T) {
+	query, args := newSelectBuilder("id, title", "articles").
+		Where("id < ?", 10).
+		Where("status = ?", "published").
+		OrderBy("id desc").
+		Limit(5).
+		Build()
+
+	want := "SELECT id, title\n\tFROM articles\n\tWHERE id < ? AND status = ?\n\tORDER BY id desc\n\tLIMIT 5"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if wantArgs := []interface{}{10, "published"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestSelectBuilderMinimal(t *testing.T) {
+	// 条件・並び順・件数を指定しない場合は、それぞれの句を含めません。
+	query, args := newSelectBuilder("*", "tags").Limit(0).Build()
+
+	if want := "SELECT *\n\tFROM tags"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 0 {
+		t.Errorf("args = %v, want none", args)
+	}
+}
+
+func TestSelectBuilderTableNames(t *testing.T) {
+	renamed := DefaultTableNames
+	renamed.Articles = "blog_articles"
+	SetTableNames(renamed)
+	defer SetTableNames(DefaultTableNames)
+
+	query, _ := newSelectBuilder("articles.id", "articles").Where("articles.status = 'articles'").Build()
+
+	want := "SELECT blog_articles.id\n\tFROM blog_articles\n\tWHERE blog_articles.status = 'articles'"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+)
+
+// ErrSeriesNotFound ...
+var ErrSeriesNotFound = errors.New("series not found")
+
+// SeriesCreate ...
+func SeriesCreate(series *model.Series) error {
+	defer logSlowQuery("SeriesCreate", time.Now())
+
+	series.Created = timeNow()
+
+	query := buildQuery(`INSERT INTO series (title, created) VALUES (:title, :created);`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("SeriesCreate: %w", err))
+	}
+
+	res, err := tx.NamedExec(query, series)
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("SeriesCreate: %w", err))
+	}
+
+	// 作成されたレコードの ID を構造体にセットします。
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("SeriesCreate: %w", err))
+	}
+	series.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("SeriesCreate: %w", err))
+	}
+	return nil
+}
+
+// SeriesAddArticle ...
+func SeriesAddArticle(seriesID, articleID, position int) error {
+	defer logSlowQuery("SeriesAddArticle", time.Now())
+
+	// 記事が既にシリーズに含まれている場合は、順番のみを変更します。
+	query := buildQuery(`INSERT INTO article_series (article_id, series_id, position)
+	VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE position = VALUES(position);`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("SeriesAddArticle: %w", err))
+	}
+
+	if _, err := tx.Exec(query, articleID, seriesID, position); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("SeriesAddArticle: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("SeriesAddArticle: %w", err))
+	}
+	return nil
+}
+
+// SeriesGetWithArticles ...
+func SeriesGetWithArticles(seriesID int) (*model.Series, error) {
+	defer logSlowQuery("SeriesGetWithArticles", time.Now())
+
+	var series model.Series
+	if err := getDB().Get(&series, buildQuery(`SELECT * FROM series WHERE id = ?;`), seriesID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("SeriesGetWithArticles: %w", err))
+	}
+
+	// シリーズの記事を順番の昇順に取得します。同じ順番の記事は ID の昇順に並べます。
+	// ゴミ箱に入っている記事はシリーズに表示しません。
+	query := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	INNER JOIN article_series AS s ON s.article_id = articles.id
+	WHERE s.series_id = ? AND articles.deleted_at IS NULL
+	ORDER BY s.position, articles.id;`)
+
+	series.Articles = []*model.Article{}
+	if err := getDB().Select(&series.Articles, query, seriesID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("SeriesGetWithArticles: %w", err))
+	}
+
+	return &series, nil
+}
+
+// ArticleSeriesNeighbors ...
+func ArticleSeriesNeighbors(articleID int) ([]*model.SeriesNeighbors, error) {
+	defer logSlowQuery("ArticleSeriesNeighbors", time.Now())
+
+	// 記事が含まれるシリーズと、シリーズ内での順番を取得します。
+	// 一つの記事が複数のシリーズに含まれる場合は、シリーズごとに前後の記事を返却します。
+	q1 := buildQuery(`SELECT series_id, position
+	FROM article_series
+	WHERE article_id = ?
+	ORDER BY series_id;`)
+
+	var memberships []struct {
+		SeriesID int `db:"series_id"`
+		Position int `db:"position"`
+	}
+	if err := getDB().Select(&memberships, q1, articleID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleSeriesNeighbors: %w", err))
+	}
+
+	// 前後の記事は、同じシリーズで順番が一つ前・一つ後の公開中の記事です。
+	// 同じ順番の記事がある場合は ID で前後を判定します。
+	q2 := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	INNER JOIN article_series AS s ON s.article_id = articles.id
+	WHERE s.series_id = ?
+	AND (s.position < ? OR (s.position = ? AND articles.id < ?))
+	AND articles.status = ? AND articles.deleted_at IS NULL
+	ORDER BY s.position desc, articles.id desc
+	LIMIT 1;`)
+	q3 := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	INNER JOIN article_series AS s ON s.article_id = articles.id
+	WHERE s.series_id = ?
+	AND (s.position > ? OR (s.position = ? AND articles.id > ?))
+	AND articles.status = ? AND articles.deleted_at IS NULL
+	ORDER BY s.position, articles.id
+	LIMIT 1;`)
+
+	neighbors := make([]*model.SeriesNeighbors, 0, len(memberships))
+	for _, m := range memberships {
+		n := &model.SeriesNeighbors{SeriesID: m.SeriesID, Position: m.Position}
+
+		prev, err := seriesNeighbor(q2, m.SeriesID, m.Position, articleID)
+		if err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleSeriesNeighbors: %w", err))
+		}
+		next, err := seriesNeighbor(q3, m.SeriesID, m.Position, articleID)
+		if err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleSeriesNeighbors: %w", err))
+		}
+		n.Prev, n.Next = prev, next
+
+		neighbors = append(neighbors, n)
+	}
+
+	return neighbors, nil
+}
+
+// seriesNeighbor はシリーズ内で前または後の記事を一件取得します。
+// シリーズの最初または最後の記事で、前後の記事がない場合は nil を返却します。
+func seriesNeighbor(query string, seriesID, position, articleID int) (*model.Article, error) {
+	var article model.Article
+	err := getDB().Get(&article, query, seriesID, position, position, articleID, model.ArticleStatusPublished)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
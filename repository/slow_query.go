@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"log"
+	"time"
+)
+
+// スロークエリとして記録する閾値と出力先のロガーです。
+// ロガーが設定されていない場合はログを出力しません。
+var (
+	slowQueryThreshold time.Duration
+	slowQueryLogger    *log.Logger
+)
+
+// SetSlowQueryLogger ...
+func SetSlowQueryLogger(threshold time.Duration, logger *log.Logger) {
+	slowQueryThreshold = threshold
+	slowQueryLogger = logger
+}
+
+// logSlowQuery は処理の開始時刻からの経過時間が閾値を超えた場合にログを出力します。
+// 各リポジトリ関数の先頭で defer logSlowQuery("関数名", time.Now()) の形で呼び出します。
+func logSlowQuery(name string, start time.Time) {
+	if slowQueryLogger == nil {
+		return
+	}
+
+	if elapsed := time.Since(start); elapsed > slowQueryThreshold {
+		slowQueryLogger.Printf("slow query: %s took %s", name, elapsed)
+	}
+}
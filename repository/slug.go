@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// slugify は文字列から URL に利用できるスラッグを生成します。
+// 英数字以外の文字はハイフンに置き換え、連続するハイフンは一つにまとめます。
+// 英数字が一文字も含まれない場合は fallback を返却します。
+func slugify(s, fallback string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteRune('-')
+			hyphen = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return fallback
+	}
+	return slug
+}
+
+// uniqueSlug は指定したテーブル内で重複しないスラッグを返却します。
+// 既に同じスラッグが使われている場合は "-2"、"-3" のように数字を付けます。
+// 存在しないスラッグを FOR UPDATE で確認するとインデックスのギャップがロックされ、同時に作成した場合にデッドロックするため、ロックせずに確認します。
+// 同時に同じスラッグで作成された場合は、スラッグの一意制約により後から保存した側がエラーになります。
+func uniqueSlug(tx *sqlx.Tx, table, base string) (string, error) {
+	query := buildQuery(fmt.Sprintf(`SELECT slug FROM %s WHERE slug = ? OR slug LIKE ?;`, table))
+
+	var slugs []string
+	if err := tx.Select(&slugs, query, base, likeEscaper.Replace(base)+"-%"); err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(slugs))
+	for _, s := range slugs {
+		used[s] = true
+	}
+
+	slug := base
+	for i := 2; used[slug]; i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug, nil
+}
+
+// slugBackfillBatchSize はスラッグを生成する記事を一度に読み込む件数です。
+const slugBackfillBatchSize = 100
+
+// BackfillSlugs ...
+func BackfillSlugs() (updated int, err error) {
+	defer logSlowQuery("BackfillSlugs", time.Now())
+
+	// スラッグが空の記事のみを対象にするため、何度実行しても生成済みのスラッグは変わりません。
+	q1 := buildQuery(`SELECT id, title FROM articles WHERE slug = '' ORDER BY id LIMIT ?;`)
+	q2 := buildQuery(`UPDATE articles SET slug = ? WHERE id = ? AND slug = '';`)
+
+	for {
+		var articles []*model.Article
+		if err := getDB().Select(&articles, q1, slugBackfillBatchSize); err != nil {
+			return updated, ClassifyError(fmt.Errorf("BackfillSlugs: %w", err))
+		}
+		if len(articles) == 0 {
+			return updated, nil
+		}
+
+		for _, article := range articles {
+			// 他の記事と重複しないスラッグを確認してから保存するため、一件ずつトランザクションを分けます。
+			tx, err := beginTx()
+			if err != nil {
+				return updated, ClassifyError(fmt.Errorf("BackfillSlugs: %w", err))
+			}
+
+			slug, err := uniqueSlug(tx, "articles", slugify(article.Title, "article"))
+			if err != nil {
+				tx.Rollback()
+				return updated, ClassifyError(fmt.Errorf("BackfillSlugs: %w", err))
+			}
+
+			res, err := tx.Exec(q2, slug, article.ID)
+			if err != nil {
+				tx.Rollback()
+				return updated, ClassifyError(fmt.Errorf("BackfillSlugs: %w", err))
+			}
+
+			if err := tx.Commit(); err != nil {
+				return updated, ClassifyError(fmt.Errorf("BackfillSlugs: %w", err))
+			}
+
+			// 読み込んだ後に他の処理でスラッグが設定された記事は数えません。
+			if n, _ := res.RowsAffected(); n > 0 {
+				updated++
+			}
+		}
+	}
+}
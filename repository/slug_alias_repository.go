@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// regenerateArticleSlug は記事のタイトルからスラッグを生成し直し、構造体に設定します。
+// 変更前のスラッグは古い URL からリダイレクトできるよう、別名として slug_aliases テーブルに保存します。
+func regenerateArticleSlug(tx *sqlx.Tx, article *model.Article) error {
+	var current string
+	if err := tx.Get(&current, buildQuery(`SELECT slug FROM articles WHERE id = ? FOR UPDATE;`), article.ID); err != nil {
+		return err
+	}
+
+	// タイトルから生成したスラッグが変わらない場合は何もしません。
+	base := slugify(article.Title, "article")
+	if base == current {
+		article.Slug = current
+		return nil
+	}
+
+	slug, err := changeArticleSlug(tx, article.ID, current, base)
+	if err != nil {
+		return err
+	}
+
+	article.Slug = slug
+	return nil
+}
+
+// changeArticleSlug は記事のスラッグを base から生成した重複しないスラッグに変更し、変更後のスラッグを返却します。
+// 変更前のスラッグ current は古い URL からリダイレクトできるよう、別名として slug_aliases テーブルに保存します。
+func changeArticleSlug(tx *sqlx.Tx, id int, current, base string) (string, error) {
+	slug, err := uniqueSlug(tx, "articles", base)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(buildQuery(`UPDATE articles SET slug = ? WHERE id = ?;`), slug, id); err != nil {
+		return "", err
+	}
+
+	// 新しいスラッグが他の記事の別名として登録されている場合は、記事のスラッグを優先するため削除します。
+	if _, err := tx.Exec(buildQuery(`DELETE FROM slug_aliases WHERE slug = ?;`), slug); err != nil {
+		return "", err
+	}
+
+	// 変更前のスラッグを別名として保存します。
+	if current != "" {
+		q := buildQuery(`INSERT INTO slug_aliases (slug, article_id, created)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE article_id = VALUES(article_id);`)
+		if _, err := tx.Exec(q, current, id, timeNow()); err != nil {
+			return "", err
+		}
+	}
+
+	return slug, nil
+}
+
+// ArticleSetSlug ...
+func ArticleSetSlug(id int, slug string) (string, error) {
+	defer logSlowQuery("ArticleSetSlug", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return "", ClassifyError(fmt.Errorf("ArticleSetSlug: %w", err))
+	}
+
+	// 現在のスラッグをロックしながら取得します。
+	// ゴミ箱に入っている記事のスラッグは変更しません。
+	var current string
+	q1 := buildQuery(`SELECT slug FROM articles WHERE id = ? AND deleted_at IS NULL FOR UPDATE;`)
+	if err := tx.Get(&current, q1, id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return "", ErrArticleNotFound
+		}
+		return "", ClassifyError(fmt.Errorf("ArticleSetSlug: %w", err))
+	}
+
+	// 指定されたスラッグも URL に利用できる形に揃え、変わらない場合は何もしません。
+	base := slugify(slug, "article")
+	if base == current {
+		tx.Rollback()
+		return current, nil
+	}
+
+	changed, err := changeArticleSlug(tx, id, current, base)
+	if err != nil {
+		tx.Rollback()
+		return "", ClassifyError(fmt.Errorf("ArticleSetSlug: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", ClassifyError(fmt.Errorf("ArticleSetSlug: %w", err))
+	}
+	return changed, nil
+}
+
+// ArticleGetBySlugOrAlias ...
+func ArticleGetBySlugOrAlias(slug string) (*model.Article, error) {
+	defer logSlowQuery("ArticleGetBySlugOrAlias", time.Now())
+
+	// 現在のスラッグに一致する記事を優先して取得します。
+	article, err := ArticleGetBySlugFull(slug)
+	if err == nil {
+		return article, nil
+	}
+	if !errors.Is(err, ErrArticleNotFound) {
+		return nil, ClassifyError(fmt.Errorf("ArticleGetBySlugOrAlias: %w", err))
+	}
+
+	// 一致しない場合は、変更前のスラッグとして登録されている記事の現在のスラッグを取得します。
+	// 返却した記事のスラッグが引数と異なる場合は、呼び出し元で新しい URL にリダイレクトします。
+	var current string
+	query := buildQuery(`SELECT articles.slug
+	FROM slug_aliases AS a
+	INNER JOIN articles ON articles.id = a.article_id
+	WHERE a.slug = ?;`)
+	if err := getDB().Get(&current, query, slug); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ClassifyError(fmt.Errorf("ArticleGetBySlugOrAlias: %w", err))
+	}
+
+	return ArticleGetBySlugFull(current)
+}
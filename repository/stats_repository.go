@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+)
+
+// RepositoryStats ...
+func RepositoryStats() (*model.RepoStats, error) {
+	defer logSlowQuery("RepositoryStats", time.Now())
+
+	// 記事の件数はステータスごとに ArticleCountByStatus() で集計します。
+	articles, err := ArticleCountByStatus()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("RepositoryStats: %w", err))
+	}
+
+	// 筆者・タグ・コメントの件数はサブクエリで一回のクエリにまとめて取得します。
+	query := buildQuery(`SELECT
+		(SELECT COUNT(*) FROM writers) AS writers,
+		(SELECT COUNT(*) FROM tags) AS tags,
+		(SELECT COUNT(*) FROM comments) AS comments;`)
+
+	var stats model.RepoStats
+	if err := getDB().Get(&stats, query); err != nil {
+		return nil, ClassifyError(fmt.Errorf("RepositoryStats: %w", err))
+	}
+	stats.ArticlesByStatus = articles
+
+	return &stats, nil
+}
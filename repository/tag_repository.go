@@ -1,18 +1,26 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"go-tech-blog/model"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // TagListByArticleID ...
 func TagListByArticleID(articleID int) ([]*model.Tag, error) {
+	defer logSlowQuery("TagListByArticleID", time.Now())
+
 	// articles_tags テーブルから tag_id を取得します。
-	q1 := `SELECT tag_id FROM articles_tags WHERE article_id = ?;`
+	q1 := buildQuery(`SELECT tag_id FROM articles_tags WHERE article_id = ?;`)
 	var tagIDs []int
-	if err := db.Select(&tagIDs, q1, articleID); err != nil {
-		return nil, err
+	if err := getDB().Select(&tagIDs, q1, articleID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("TagListByArticleID: %w", err))
 	}
 
 	// タグ情報を格納する変数を宣言します。
@@ -24,20 +32,20 @@ func TagListByArticleID(articleID int) ([]*model.Tag, error) {
 	}
 
 	// tags テーブルからタグ情報を取得するクエリ文字列を生成します。
-	q2 := `SELECT * FROM tags WHERE id IN(?);`
+	q2 := buildQuery(`SELECT * FROM tags WHERE id IN(?);`)
 
 	// IN 句を利用するクエリを作成するには sqlx パッケージの In() 関数を利用します。
 	query, args, err := sqlx.In(q2, tagIDs)
 	if err != nil {
-		return nil, err
+		return nil, ClassifyError(fmt.Errorf("TagListByArticleID: %w", err))
 	}
 
 	// sqlx.In() 関数で生成されたクエリ文字列をパラメータを利用して SQL を実行します。
 	// sqlx.Select() 関数の第三引数は可変長のパラメータを取ります。
 	// args 変数はスライス型なので、...で展開して渡します。
 	// 参考：https://golang.org/ref/spec#Passing_arguments_to_..._parameters
-	if err := db.Select(&tags, query, args...); err != nil {
-		return nil, err
+	if err := getDB().Select(&tags, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("TagListByArticleID: %w", err))
 	}
 
 	return tags, nil
@@ -45,6 +53,8 @@ func TagListByArticleID(articleID int) ([]*model.Tag, error) {
 
 // TagListMapByArticleIDs ...
 func TagListMapByArticleIDs(articleIDs []int) (map[int][]*model.Tag, error) {
+	defer logSlowQuery("TagListMapByArticleIDs", time.Now())
+
 	// タグ情報を格納するマップを生成します。
 	// マップのキーに記事ID、バリューにタグのスライスを格納します。
 	m := make(map[int][]*model.Tag)
@@ -55,23 +65,23 @@ func TagListMapByArticleIDs(articleIDs []int) (map[int][]*model.Tag, error) {
 	}
 
 	// articles_tags テーブルからデータを取得します。
-	q1 := `SELECT
+	q1 := buildQuery(`SELECT
 		at.article_id AS article_id,
 		at.tag_id AS tag_id,
 		tags.id AS 'tag.id',
 		tags.name AS 'tag.name'
 	FROM articles_tags AS at
 	INNER JOIN tags ON tags.id = at.tag_id
-	WHERE article_id IN(?);`
+	WHERE article_id IN(?);`)
 
 	q2, args, err := sqlx.In(q1, articleIDs)
 	if err != nil {
-		return nil, err
+		return nil, ClassifyError(fmt.Errorf("TagListMapByArticleIDs: %w", err))
 	}
 
 	var articleTagList []*model.ArticleTag
-	if err := db.Select(&articleTagList, q2, args...); err != nil {
-		return nil, err
+	if err := getDB().Select(&articleTagList, q2, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("TagListMapByArticleIDs: %w", err))
 	}
 
 	// 取得したデータを map に格納し直します。
@@ -81,3 +91,490 @@ func TagListMapByArticleIDs(articleIDs []int) (map[int][]*model.Tag, error) {
 
 	return m, nil
 }
+
+// tagConcatSeparator は GROUP_CONCAT でタグ名を連結する際の区切り文字です。
+// タグ名にカンマが含まれても分割できるよう、通常の文字列に現れない制御文字（US）を利用します。
+const tagConcatSeparator = "\x1f"
+
+// ArticleListByCursorConcatTags ...
+func ArticleListByCursorConcatTags(cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListByCursorConcatTags", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 記事データとタグ情報を一回のクエリで取得します。
+	// タグは GROUP_CONCAT で一つの文字列に連結し、Go 側で分割して構造体に格納します。
+	// タグのない記事は LEFT JOIN の結果が NULL になるため NullString で受け取ります。
+	// 連結結果は group_concat_max_len（デフォルト 1024 バイト）を超えると警告なしに切り詰められるため、
+	// タグの件数も合わせて取得し、分割した件数と一致しない記事はタグを取得し直します。
+	// ArticleListByCursor() と同じく、公開中の一覧と同じ記事のみを取得します。
+	query := buildQuery(`SELECT
+		` + articleColumnsQualified + `,
+		COUNT(tags.id) AS tag_count,
+		GROUP_CONCAT(tags.id ORDER BY tags.id SEPARATOR ',') AS tag_ids,
+		GROUP_CONCAT(tags.name ORDER BY tags.id SEPARATOR '` + tagConcatSeparator + `') AS tag_names
+	FROM articles
+	LEFT JOIN articles_tags AS at ON at.article_id = articles.id
+	LEFT JOIN tags ON tags.id = at.tag_id
+	WHERE articles.id < ?
+	AND ` + publicArticleFilter + `
+	GROUP BY articles.id
+	ORDER BY articles.id desc
+	LIMIT 10`)
+
+	var rows []struct {
+		model.Article
+		TagCount int            `db:"tag_count"`
+		TagIDs   sql.NullString `db:"tag_ids"`
+		TagNames sql.NullString `db:"tag_names"`
+	}
+	if err := getDB().Select(&rows, query, maxID); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListByCursorConcatTags: %w", err))
+	}
+
+	articles := make([]*model.Article, 0, len(rows))
+	var truncated []*model.Article
+	for i := range rows {
+		article := rows[i].Article
+
+		// タグのない記事は空のスライスにします。
+		article.Tags = []*model.Tag{}
+		if rows[i].TagIDs.Valid {
+			ids := strings.Split(rows[i].TagIDs.String, ",")
+			names := strings.Split(rows[i].TagNames.String, tagConcatSeparator)
+
+			// 連結結果が切り詰められている場合は、分割した件数がタグの件数と一致しません。
+			if len(ids) != rows[i].TagCount || len(names) != rows[i].TagCount {
+				truncated = append(truncated, &article)
+				articles = append(articles, &article)
+				continue
+			}
+
+			for j, id := range ids {
+				tagID, err := strconv.Atoi(id)
+				if err != nil {
+					return nil, ClassifyError(fmt.Errorf("ArticleListByCursorConcatTags: %w", err))
+				}
+				article.Tags = append(article.Tags, &model.Tag{ID: tagID, Name: names[j]})
+			}
+		}
+
+		articles = append(articles, &article)
+	}
+
+	// 切り詰められた記事のタグは、連結せずに取得し直します。
+	if len(truncated) > 0 {
+		ids := make([]int, len(truncated))
+		for i, article := range truncated {
+			ids[i] = article.ID
+		}
+
+		tagListMap, err := TagListMapByArticleIDs(ids)
+		if err != nil {
+			return nil, ClassifyError(fmt.Errorf("ArticleListByCursorConcatTags: %w", err))
+		}
+		for _, article := range truncated {
+			if tags, ok := tagListMap[article.ID]; ok {
+				article.Tags = tags
+			}
+		}
+	}
+
+	return articles, nil
+}
+
+// hiddenTagFilter は非公開のタグが付いている記事を除外する条件です。
+// 公開する一覧を取得するクエリの WHERE 句に追加します。管理画面の一覧では利用しません。
+const hiddenTagFilter = `NOT EXISTS (
+		SELECT 1 FROM articles_tags AS hidden_at
+		INNER JOIN tags AS hidden_tags ON hidden_tags.id = hidden_at.tag_id
+		WHERE hidden_at.article_id = articles.id AND hidden_tags.hidden = 1
+	)`
+
+// TagSetHidden ...
+func TagSetHidden(tagID int, hidden bool) error {
+	defer logSlowQuery("TagSetHidden", time.Now())
+
+	query := buildQuery(`UPDATE tags SET hidden = ? WHERE id = ?;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("TagSetHidden: %w", err))
+	}
+
+	res, err := tx.Exec(query, hidden, tagID)
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("TagSetHidden: %w", err))
+	}
+
+	// 値が変わらない場合も 0 件になるため、0 件の場合はタグが存在するかを確認します。
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists int
+		err := tx.Get(&exists, buildQuery(`SELECT 1 FROM tags WHERE id = ?;`), tagID)
+		if err == sql.ErrNoRows {
+			tx.Rollback()
+			return ErrTagNotFound
+		}
+		if err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("TagSetHidden: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("TagSetHidden: %w", err))
+	}
+	return nil
+}
+
+// ErrTagNotFound ...
+var ErrTagNotFound = errors.New("tag not found")
+
+// TagDelete ...
+func TagDelete(tagID int) error {
+	defer logSlowQuery("TagDelete", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("TagDelete: %w", err))
+	}
+
+	// 紐付けを削除した後にタグ名のキャッシュを更新するため、タグが付いている記事を先に取得しておきます。
+	var articleIDs []int
+	if err := tx.Select(&articleIDs, buildQuery(`SELECT article_id FROM articles_tags WHERE tag_id = ? FOR UPDATE;`), tagID); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("TagDelete: %w", err))
+	}
+
+	// 外部キー制約があるため、先に記事との紐付けを削除します。
+	if _, err := tx.Exec(buildQuery(`DELETE FROM articles_tags WHERE tag_id = ?;`), tagID); err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("TagDelete: %w", err))
+	}
+
+	// 筆者のデフォルトのタグからも外します。
+	if _, err := tx.Exec(buildQuery(`DELETE FROM writer_default_tags WHERE tag_id = ?;`), tagID); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("TagDelete: %w", err))
+	}
+
+	// タグを削除します。
+	res, err := tx.Exec(buildQuery(`DELETE FROM tags WHERE id = ?;`), tagID)
+	if err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("TagDelete: %w", err))
+	}
+
+	// 削除対象がない場合はタグが存在しません。
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+		return ErrTagNotFound
+	}
+
+	// タグが付いていた記事に、削除したタグ名が表示されないようキャッシュを更新します。
+	for _, articleID := range articleIDs {
+		if err := refreshTagCache(tx, articleID); err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("TagDelete: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("TagDelete: %w", err))
+	}
+	return nil
+}
+
+// ArticleSetTags ...
+func ArticleSetTags(articleID int, tagIDs []int) error {
+	defer logSlowQuery("ArticleSetTags", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetTags: %w", err))
+	}
+
+	// 記事に紐づいているタグをすべて外してから、指定されたタグを紐づけ直します。
+	if err := deleteArticleTags(tx, []int{articleID}); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSetTags: %w", err))
+	}
+
+	// 同じタグが複数回指定されても一件のみ紐づけます。
+	// INSERT IGNORE は存在しないタグ ID による外部キーのエラーも無視してしまうため、重複のみを ON DUPLICATE KEY UPDATE で無視します。
+	for _, tagID := range tagIDs {
+		q := buildQuery(`INSERT INTO articles_tags (article_id, tag_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE tag_id = tag_id;`)
+		if _, err := tx.Exec(q, articleID, tagID); err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("ArticleSetTags: %w", err))
+		}
+	}
+
+	// 一覧画面で JOIN せずにタグを表示できるように、タグ名のキャッシュを更新します。
+	if err := refreshTagCache(tx, articleID); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("ArticleSetTags: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("ArticleSetTags: %w", err))
+	}
+	return nil
+}
+
+// deleteArticleTags は記事に紐づいているタグをすべて外します。
+// 記事の削除時とタグの付け直し時に、呼び出し元と同じトランザクションで紐付けを削除するために利用します。
+// タグ名のキャッシュは更新しないため、記事を残す場合は refreshTagCache() で更新してください。
+func deleteArticleTags(tx *sqlx.Tx, articleIDs []int) error {
+	q, args, err := sqlx.In(buildQuery(`DELETE FROM articles_tags WHERE article_id IN(?);`), articleIDs)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(q, args...)
+	return err
+}
+
+// ArticleDedupeTagAssociations ...
+func ArticleDedupeTagAssociations() (removed int, err error) {
+	defer logSlowQuery("ArticleDedupeTagAssociations", time.Now())
+
+	// 主キーがあるため通常は重複しませんが、主キーを付ける前のデータなどで重複している組み合わせを取得します。
+	q1 := buildQuery(`SELECT article_id, tag_id, COUNT(*) AS count
+	FROM articles_tags
+	GROUP BY article_id, tag_id
+	HAVING COUNT(*) > 1
+	FOR UPDATE;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleDedupeTagAssociations: %w", err))
+	}
+
+	var dupes []struct {
+		ArticleID int `db:"article_id"`
+		TagID     int `db:"tag_id"`
+		Count     int `db:"count"`
+	}
+	if err := tx.Select(&dupes, q1); err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("ArticleDedupeTagAssociations: %w", err))
+	}
+
+	// 重複している組み合わせをすべて削除してから、一件だけ紐づけ直します。
+	q2 := buildQuery(`DELETE FROM articles_tags WHERE article_id = ? AND tag_id = ?;`)
+	q3 := buildQuery(`INSERT INTO articles_tags (article_id, tag_id) VALUES (?, ?);`)
+	for _, d := range dupes {
+		if _, err := tx.Exec(q2, d.ArticleID, d.TagID); err != nil {
+			tx.Rollback()
+			return 0, ClassifyError(fmt.Errorf("ArticleDedupeTagAssociations: %w", err))
+		}
+		if _, err := tx.Exec(q3, d.ArticleID, d.TagID); err != nil {
+			tx.Rollback()
+			return 0, ClassifyError(fmt.Errorf("ArticleDedupeTagAssociations: %w", err))
+		}
+
+		// 重複していたタグ名がキャッシュにも含まれているため、キャッシュを更新します。
+		if err := refreshTagCache(tx, d.ArticleID); err != nil {
+			tx.Rollback()
+			return 0, ClassifyError(fmt.Errorf("ArticleDedupeTagAssociations: %w", err))
+		}
+		removed += d.Count - 1
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleDedupeTagAssociations: %w", err))
+	}
+	return removed, nil
+}
+
+// tagsCacheExpr は記事に紐づくタグ名をカンマ区切りでつなげた、tags_cache カラムに保存する値です。
+const tagsCacheExpr = `COALESCE((
+		SELECT GROUP_CONCAT(tags.name ORDER BY tags.id SEPARATOR ',')
+		FROM articles_tags AS at
+		INNER JOIN tags ON tags.id = at.tag_id
+		WHERE at.article_id = articles.id
+	), '')`
+
+// RebuildTagCache ...
+func RebuildTagCache() error {
+	defer logSlowQuery("RebuildTagCache", time.Now())
+
+	// 正規化されたテーブルを正として、すべての記事のタグ名のキャッシュを作り直します。
+	query := buildQuery(`UPDATE articles SET tags_cache = ` + tagsCacheExpr + `;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("RebuildTagCache: %w", err))
+	}
+
+	if _, err := tx.Exec(query); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("RebuildTagCache: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("RebuildTagCache: %w", err))
+	}
+	return nil
+}
+
+// refreshTagCache は記事に紐づくタグ名をカンマ区切りで tags_cache カラムに保存します。
+func refreshTagCache(tx *sqlx.Tx, articleID int) error {
+	query := buildQuery(`UPDATE articles SET tags_cache = ` + tagsCacheExpr + `
+	WHERE id = ?;`)
+
+	_, err := tx.Exec(query, articleID)
+	return err
+}
+
+// タグの一覧を取得する際の一ページあたりの件数のデフォルト値と最大値です。
+const (
+	tagDefaultPerPage = 50
+	tagMaxPerPage     = 100
+)
+
+// TagListPaged ...
+func TagListPaged(page, perPage int) ([]*model.Tag, int, error) {
+	defer logSlowQuery("TagListPaged", time.Now())
+
+	// ページ番号と件数を有効な範囲に収めます。
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = tagDefaultPerPage
+	}
+	if perPage > tagMaxPerPage {
+		perPage = tagMaxPerPage
+	}
+
+	// タグの総数を取得します。
+	var total int
+	if err := getDB().Get(&total, buildQuery(`SELECT COUNT(*) FROM tags;`)); err != nil {
+		return nil, 0, ClassifyError(fmt.Errorf("TagListPaged: %w", err))
+	}
+
+	// タグ名の順に指定したページのタグを取得します。
+	query := buildQuery(`SELECT *
+	FROM tags
+	ORDER BY name, id
+	LIMIT ? OFFSET ?;`)
+
+	tags := make([]*model.Tag, 0, perPage)
+	if err := getDB().Select(&tags, query, perPage, (page-1)*perPage); err != nil {
+		return nil, 0, ClassifyError(fmt.Errorf("TagListPaged: %w", err))
+	}
+
+	return tags, total, nil
+}
+
+// TagCreate ...
+func TagCreate(name string) (*model.Tag, error) {
+	defer logSlowQuery("TagCreate", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("TagCreate: %w", err))
+	}
+
+	// 同じ名前のタグが既にある場合は作成せず、既存のタグを返却します。
+	id, err := ensureTag(tx, name)
+	if err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("TagCreate: %w", err))
+	}
+
+	// 作成された、または既存のタグを取得します。
+	var tag model.Tag
+	if err := tx.Get(&tag, buildQuery(`SELECT * FROM tags WHERE id = ?;`), id); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("TagCreate: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("TagCreate: %w", err))
+	}
+
+	return &tag, nil
+}
+
+// ensureTag は指定した名前のタグを作成し、タグの ID を返却します。
+// 同じ名前のタグが既にある場合は作成せず、既存のタグの ID を返却します。
+// 存在確認をしてから作成すると同時に作成された場合に一方が失敗するため、
+// 一意制約を利用して INSERT ... ON DUPLICATE KEY UPDATE で一度に処理します。
+// LAST_INSERT_ID(id) を指定すると、既存のタグの場合もその ID が LastInsertId() で取得できます。
+func ensureTag(tx *sqlx.Tx, name string) (int, error) {
+	q := buildQuery(`INSERT INTO tags (name) VALUES (?)
+	ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id);`)
+
+	res, err := tx.Exec(q, name)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// TagRelated ...
+func TagRelated(tagID, limit int) ([]*model.TagWithCount, error) {
+	defer logSlowQuery("TagRelated", time.Now())
+
+	// 取得件数が 0 以下の場合は空のスライスを返却します。
+	if limit <= 0 {
+		return []*model.TagWithCount{}, nil
+	}
+
+	// 指定したタグが付いている記事に、一緒に付いているタグを集計します。
+	// 一緒に付いている記事の数が多い順に並べ、同じ数の場合はタグ名の順にします。
+	query := buildQuery(`SELECT tags.*, COUNT(*) AS count
+	FROM articles_tags AS base
+	INNER JOIN articles_tags AS co ON co.article_id = base.article_id AND co.tag_id <> base.tag_id
+	INNER JOIN tags ON tags.id = co.tag_id
+	WHERE base.tag_id = ?
+	GROUP BY tags.id
+	ORDER BY count desc, tags.name, tags.id
+	LIMIT ?;`)
+
+	tags := make([]*model.TagWithCount, 0, limit)
+	if err := getDB().Select(&tags, query, tagID, limit); err != nil {
+		return nil, ClassifyError(fmt.Errorf("TagRelated: %w", err))
+	}
+
+	return tags, nil
+}
+
+// TagLatestUpdated ...
+func TagLatestUpdated(tagID int) (time.Time, error) {
+	defer logSlowQuery("TagLatestUpdated", time.Now())
+
+	// タグが付いた記事のうち、最も新しい更新日時を取得します。
+	// 記事をゴミ箱に入れたり下書きに戻したりした場合も一覧が変わるため、ステータスに関わらずすべての記事を対象にします。
+	query := buildQuery(`SELECT MAX(articles.updated)
+	FROM articles
+	INNER JOIN articles_tags AS at ON at.article_id = articles.id
+	WHERE at.tag_id = ?;`)
+
+	// 記事が一件もない場合は NULL になるため、ゼロ値の日時を返却します。
+	var updated sql.NullTime
+	if err := getDB().Get(&updated, query, tagID); err != nil {
+		return time.Time{}, ClassifyError(fmt.Errorf("TagLatestUpdated: %w", err))
+	}
+	if !updated.Valid {
+		return time.Time{}, nil
+	}
+
+	return updated.Time, nil
+}
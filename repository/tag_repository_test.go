@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTagCreateConcurrent(t *testing.T) {
+	d := NewTestDB(t)
+
+	// 同じ名前のタグを複数の goroutine から同時に作成し、すべて成功して同じタグが返却されることを確認します。
+	const workers = 20
+	ids := make([]int, workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			tag, err := TagCreate("go")
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ids[i] = tag.ID
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("TagCreate() #%d error = %v", i, err)
+		}
+	}
+	for i, id := range ids {
+		if id != ids[0] {
+			t.Errorf("TagCreate() #%d id = %d, want %d", i, id, ids[0])
+		}
+	}
+
+	var count int
+	if err := d.Get(&count, `SELECT COUNT(*) FROM tags WHERE name = ?;`, "go"); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("tags named go = %d, want 1", count)
+	}
+}
+
+func TestArticleListByCursorConcatTags(t *testing.T) {
+	NewTestDB(t)
+
+	article := &model.Article{Title: "published", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+	if _, err := ArticleCreate(&model.Article{Title: "draft", Body: "body", Status: model.ArticleStatusDraft}); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+
+	// タグ名の合計が group_concat_max_len のデフォルト（1024 バイト）を超えるようにタグを付けます。
+	var tagIDs []int
+	names := map[int]string{}
+	for i := 0; i < 30; i++ {
+		name := fmt.Sprintf("%02d-%s", i, strings.Repeat("t", 40))
+		tag, err := TagCreate(name)
+		if err != nil {
+			t.Fatalf("TagCreate: %v", err)
+		}
+		tagIDs = append(tagIDs, tag.ID)
+		names[tag.ID] = name
+	}
+	if err := ArticleSetTags(article.ID, tagIDs); err != nil {
+		t.Fatalf("ArticleSetTags: %v", err)
+	}
+
+	articles, err := ArticleListByCursorConcatTags(0)
+	if err != nil {
+		t.Fatalf("ArticleListByCursorConcatTags: %v", err)
+	}
+
+	// 下書きは含まれず、切り詰められたタグも正しく取得し直されます。
+	if len(articles) != 1 || articles[0].ID != article.ID {
+		t.Fatalf("ArticleListByCursorConcatTags() = %d articles, want only the published article", len(articles))
+	}
+	if got := len(articles[0].Tags); got != len(tagIDs) {
+		t.Fatalf("tags = %d, want %d", got, len(tagIDs))
+	}
+	for _, tag := range articles[0].Tags {
+		if tag.Name != names[tag.ID] {
+			t.Errorf("tag %d name = %q, want %q", tag.ID, tag.Name, names[tag.ID])
+		}
+	}
+}
+
+func TestTagDeleteRefreshesTagCache(t *testing.T) {
+	d := NewTestDB(t)
+
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+	goTag, err := TagCreate("go")
+	if err != nil {
+		t.Fatalf("TagCreate: %v", err)
+	}
+	mysqlTag, err := TagCreate("mysql")
+	if err != nil {
+		t.Fatalf("TagCreate: %v", err)
+	}
+	if err := ArticleSetTags(article.ID, []int{goTag.ID, mysqlTag.ID}); err != nil {
+		t.Fatalf("ArticleSetTags: %v", err)
+	}
+
+	if err := TagDelete(goTag.ID); err != nil {
+		t.Fatalf("TagDelete: %v", err)
+	}
+
+	var cache string
+	if err := d.Get(&cache, `SELECT tags_cache FROM articles WHERE id = ?;`, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if cache != "mysql" {
+		t.Errorf("tags_cache = %q, want %q", cache, "mysql")
+	}
+
+	ids, err := VerifyDenormalized()
+	if err != nil {
+		t.Fatalf("VerifyDenormalized: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("VerifyDenormalized() = %v, want none", ids)
+	}
+}
+
+func TestTagListMapByArticleIDsEmpty(t *testing.T) {
+	// 記事が一件もない場合は、DB に問い合わせずに空のマップを返却します。
+	for _, ids := range [][]int{nil, {}} {
+		m, err := TagListMapByArticleIDs(ids)
+		if err != nil {
+			t.Fatalf("TagListMapByArticleIDs(%v) error = %v", ids, err)
+		}
+		if m == nil || len(m) != 0 {
+			t.Errorf("TagListMapByArticleIDs(%v) = %v, want an empty map", ids, m)
+		}
+	}
+}
+
+func TestArticleSetTagsDuplicateAndUnknown(t *testing.T) {
+	d := NewTestDB(t)
+
+	article := &model.Article{Title: "title", Body: "body"}
+	if _, err := ArticleCreate(article); err != nil {
+		t.Fatalf("ArticleCreate: %v", err)
+	}
+	tag, err := TagCreate("go")
+	if err != nil {
+		t.Fatalf("TagCreate: %v", err)
+	}
+
+	// 同じタグが複数回指定されても、一件のみ紐づけます。
+	if err := ArticleSetTags(article.ID, []int{tag.ID, tag.ID, tag.ID}); err != nil {
+		t.Fatalf("ArticleSetTags: %v", err)
+	}
+	var count int
+	if err := d.Get(&count, `SELECT COUNT(*) FROM articles_tags WHERE article_id = ?;`, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("articles_tags = %d, want 1", count)
+	}
+
+	// 存在しないタグ ID はエラーにし、紐付けは変更しません。
+	if err := ArticleSetTags(article.ID, []int{tag.ID + 1}); err == nil {
+		t.Error("ArticleSetTags(unknown tag id) error = nil")
+	}
+	var tagIDs []int
+	if err := d.Select(&tagIDs, `SELECT tag_id FROM articles_tags WHERE article_id = ?;`, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(tagIDs) != 1 || tagIDs[0] != tag.ID {
+		t.Errorf("articles_tags after unknown tag id = %v, want [%d]", tagIDs, tag.ID)
+	}
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationsDir はマイグレーションファイルを配置しているディレクトリです。
+const migrationsDir = "../db/migrations"
+
+// NewTestDB はテスト用のデータベースに接続し、マイグレーションを適用したうえでリポジトリに設定して返却します。
+// リポジトリのクエリは FOR UPDATE や ON DUPLICATE KEY UPDATE などの MySQL の構文を使っているため、
+// SQLite ではなく環境変数 TEST_DSN で指定した MySQL のデータベースを利用します。
+// TEST_DSN が設定されていない場合はテストをスキップします。
+// 既存のテーブルはすべて削除して作り直すため、必ずテスト専用のデータベースを指定してください。
+func NewTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+
+	d, err := sqlx.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("sqlx.Open: %v", err)
+	}
+	if err := d.Ping(); err != nil {
+		d.Close()
+		t.Fatalf("db.Ping: %v", err)
+	}
+
+	// テーブルの作成と削除は接続ごとの設定に依存するため、接続を一つに固定して実行します。
+	d.SetMaxOpenConns(1)
+	dropTestTables(t, d)
+	migrateTestDB(t, d)
+	d.SetMaxOpenConns(0)
+
+	Init(d)
+	t.Cleanup(func() {
+		Close()
+	})
+
+	return d
+}
+
+// dropTestTables はテスト用のデータベースにあるテーブルをすべて削除します。
+func dropTestTables(t *testing.T, d *sqlx.DB) {
+	t.Helper()
+
+	var tables []string
+	if err := d.Select(&tables, `SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE();`); err != nil {
+		t.Fatalf("dropTestTables: %v", err)
+	}
+
+	// 外部キー制約があるため、制約のチェックを無効にしてから削除します。
+	d.MustExec(`SET FOREIGN_KEY_CHECKS = 0;`)
+	for _, table := range tables {
+		d.MustExec("DROP TABLE `" + table + "`;")
+	}
+	d.MustExec(`SET FOREIGN_KEY_CHECKS = 1;`)
+}
+
+// migrateTestDB はマイグレーションファイルの Up のセクションをファイル名の順に実行します。
+func migrateTestDB(t *testing.T, d *sqlx.DB) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if err != nil {
+		t.Fatalf("migrateTestDB: %v", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("migrateTestDB: %v", err)
+		}
+
+		for _, stmt := range upStatements(string(b)) {
+			if _, err := d.Exec(stmt); err != nil {
+				t.Fatalf("migrateTestDB: %s: %v", filepath.Base(file), err)
+			}
+		}
+	}
+}
+
+// upStatements はマイグレーションファイルの Up のセクションを SQL 文ごとに分けて返却します。
+// マイグレーションファイルでは文字列の中でセミコロンを使っていないため、行末のセミコロンで区切ります。
+func upStatements(migration string) []string {
+	if i := strings.Index(migration, "-- +goose Down"); i >= 0 {
+		migration = migration[:i]
+	}
+
+	var stmts []string
+	var b strings.Builder
+	for _, line := range strings.Split(migration, "\n") {
+		// コメントの行は実行しません。
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+
+		b.WriteString(line)
+		b.WriteString("\n")
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if stmt := strings.TrimSpace(b.String()); stmt != ";" {
+				stmts = append(stmts, stmt)
+			}
+			b.Reset()
+		}
+	}
+	return stmts
+}
+
+func TestUpStatements(t *testing.T) {
+	migration := `-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+update writers set email = CONCAT('writer', id, '@example.com') where email is null;
+
+ALTER TABLE writers
+  MODIFY COLUMN email varchar(255) NOT NULL,
+  ADD UNIQUE INDEX uq_writers_email (email);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+ALTER TABLE writers
+  DROP INDEX uq_writers_email;
+`
+
+	stmts := upStatements(migration)
+	if len(stmts) != 2 {
+		t.Fatalf("len(upStatements()) = %d, want 2: %q", len(stmts), stmts)
+	}
+	if !strings.HasPrefix(stmts[0], "update writers") {
+		t.Errorf("stmts[0] = %q", stmts[0])
+	}
+	if !strings.HasPrefix(stmts[1], "ALTER TABLE writers") || !strings.HasSuffix(stmts[1], "(email);") {
+		t.Errorf("stmts[1] = %q", stmts[1])
+	}
+}
+
+func TestMigrationsParse(t *testing.T) {
+	// マイグレーションファイルがすべて Up のセクションを持ち、SQL 文に分けられることを確認します。
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no migrations found")
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(upStatements(string(b))) == 0 {
+			t.Errorf("%s: no statements in the Up section", filepath.Base(file))
+		}
+	}
+}
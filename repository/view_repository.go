@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ViewWindow は同じ訪問者からの閲覧を重複して数えない期間です。
+var ViewWindow = 30 * time.Minute
+
+// ArticleIncrementViews ...
+func ArticleIncrementViews(articleID int) (int, error) {
+	defer logSlowQuery("ArticleIncrementViews", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleIncrementViews: %w", err))
+	}
+
+	count, err := incrementViews(tx, articleID)
+	if err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("ArticleIncrementViews: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ClassifyError(fmt.Errorf("ArticleIncrementViews: %w", err))
+	}
+
+	return count, nil
+}
+
+// ArticleRecordView ...
+func ArticleRecordView(articleID int, visitorToken string) (bool, error) {
+	defer logSlowQuery("ArticleRecordView", time.Now())
+
+	now := timeNow()
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return false, ClassifyError(fmt.Errorf("ArticleRecordView: %w", err))
+	}
+
+	// 同じ訪問者の直近の閲覧日時をロックしながら取得します。
+	var viewedAt time.Time
+	q1 := buildQuery(`SELECT viewed_at FROM recent_views WHERE article_id = ? AND visitor_token = ? FOR UPDATE;`)
+	err = tx.Get(&viewedAt, q1, articleID, visitorToken)
+	if err != nil && err != sql.ErrNoRows {
+		tx.Rollback()
+		return false, ClassifyError(fmt.Errorf("ArticleRecordView: %w", err))
+	}
+
+	// 期間内に閲覧済みの場合は閲覧数を増やしません。
+	if err == nil && now.Sub(viewedAt) < ViewWindow {
+		tx.Rollback()
+		return false, nil
+	}
+
+	// 閲覧日時を記録します。訪問者ごとに一行のみ保持し、最新の閲覧日時で上書きします。
+	q2 := buildQuery(`INSERT INTO recent_views (article_id, visitor_token, viewed_at) VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE viewed_at = VALUES(viewed_at);`)
+	if _, err := tx.Exec(q2, articleID, visitorToken, now); err != nil {
+		tx.Rollback()
+		return false, ClassifyError(fmt.Errorf("ArticleRecordView: %w", err))
+	}
+
+	if _, err := incrementViews(tx, articleID); err != nil {
+		tx.Rollback()
+		return false, ClassifyError(fmt.Errorf("ArticleRecordView: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, ClassifyError(fmt.Errorf("ArticleRecordView: %w", err))
+	}
+
+	return true, nil
+}
+
+// incrementViews は記事の閲覧数を 1 増やし、増やした後の閲覧数を返却します。
+// UPDATE で取得した行ロックはコミットまで保持されるため、
+// 同じトランザクション内で取得し直した値が他のリクエストの更新で古くなることはありません。
+func incrementViews(tx *sqlx.Tx, articleID int) (int, error) {
+	if _, err := tx.Exec(buildQuery(`UPDATE articles SET views = views + 1 WHERE id = ?;`), articleID); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := tx.Get(&count, buildQuery(`SELECT views FROM articles WHERE id = ?;`), articleID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrArticleNotFound
+		}
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// wordCountBatchSize は単語数などを計算し直す際に一度に読み込む記事の件数です。
+const wordCountBatchSize = 100
+
+// ArticleListLongReads ...
+func ArticleListLongReads(minWords, cursor int) ([]*model.Article, error) {
+	defer logSlowQuery("ArticleListLongReads", time.Now())
+
+	// 引数で渡されたカーソルの値が 0 以下の場合は、先頭のページとして ID の上限を設けずに取得します。
+	maxID := cursorUpperBound(int64(cursor))
+
+	// 単語数が指定した数以上の公開中の記事を、ID の降順に 10 件取得します。
+	query, args := newSelectBuilder(articleColumns, "articles").
+		Where("id < ?", maxID).
+		Where("word_count >= ?", minWords).
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("noindex = 0").
+		Where("deleted_at IS NULL").
+		Where(hiddenTagFilter).
+		OrderBy("id desc").
+		Limit(10).
+		Build()
+
+	articles := make([]*model.Article, 0, 10)
+	if err := getDB().Select(&articles, query, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleListLongReads: %w", err))
+	}
+
+	return limitArticles("ArticleListLongReads", articles, articlePageSize), nil
+}
+
+// RecomputeWordCounts ...
+func RecomputeWordCounts() (int, error) {
+	defer logSlowQuery("RecomputeWordCounts", time.Now())
+
+	query := buildQuery(`UPDATE articles SET word_count = ? WHERE id = ?;`)
+	return recomputeArticles(func(tx *sqlx.Tx, article *model.Article) error {
+		_, err := tx.Exec(query, article.CountWords(), article.ID)
+		return err
+	})
+}
+
+// recomputeArticles は本文から求めるカラムを計算し直すため、すべての記事に対して update を実行します。
+// 計算は Go で行うため、ID の昇順に一定の件数ずつ読み込み、読み込んだ件数ごとにコミットします。
+func recomputeArticles(update func(tx *sqlx.Tx, article *model.Article) error) (int, error) {
+	query := buildQuery(`SELECT id, title, body, body_format
+	FROM articles
+	WHERE id > ?
+	ORDER BY id
+	LIMIT ?;`)
+
+	updated := 0
+	lastID := 0
+	for {
+		var articles []*model.Article
+		if err := getDB().Select(&articles, query, lastID, wordCountBatchSize); err != nil {
+			return updated, err
+		}
+		if len(articles) == 0 {
+			break
+		}
+
+		// トランザクションを開始します。
+		tx, err := beginTx()
+		if err != nil {
+			return updated, err
+		}
+
+		for _, article := range articles {
+			if err := update(tx, article); err != nil {
+				tx.Rollback()
+				return updated, err
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return updated, err
+		}
+
+		updated += len(articles)
+		lastID = articles[len(articles)-1].ID
+	}
+
+	return updated, nil
+}
@@ -1,26 +1,458 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"go-tech-blog/model"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrEmailTaken ...
+var ErrEmailTaken = errors.New("email is already registered")
+
+// ErrSlugTaken ...
+var ErrSlugTaken = errors.New("slug is already used")
+
+// ErrInvalidTimezone ...
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// 一意制約違反を表すエラー番号とインデックス名です。
+const (
+	mysqlErrDuplicateEntry = 1062
+	writersEmailIndex      = "uq_writers_email"
+	writersSlugIndex       = "uq_writers_slug"
 )
 
+// writerColumns は筆者データを取得する際に SELECT 句に指定するカラムです。
+// 一意制約のために追加した slug_key カラムは構造体にないため、SELECT * ではなくこのカラムを指定します。
+const writerColumns = `writers.id, writers.name, writers.slug, writers.email, writers.timezone, writers.avatar_url`
+
 // WriterGetByID ...
 func WriterGetByID(id int) (*model.Writer, error) {
+	defer logSlowQuery("WriterGetByID", time.Now())
+
 	// writers テーブルから筆者データを一件取得します。
-	query := `SELECT * FROM writers WHERE id = ?;`
+	query := buildQuery(`SELECT ` + writerColumns + ` FROM writers WHERE id = ?;`)
 	var writer model.Writer
-	if err := db.Get(&writer, query, id); err != nil {
-		return nil, err
+	if err := getDB().Get(&writer, query, id); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterGetByID: %w", err))
 	}
 
 	// 筆者データの取得に成功したら、筆者 ID を基に複数の記事データを取得します。
 	articles, err := ArticleListByWriterID(id)
 	if err != nil {
-		return nil, err
+		return nil, ClassifyError(fmt.Errorf("WriterGetByID: %w", err))
 	}
 
 	// 記事データの取得に成功したら、記事データを筆者の構造体のフィールドに格納します。
 	writer.Articles = articles
 
+	// 記事の作成時に自動で付けるタグ名を取得します。
+	defaults, err := writerDefaultTagNames(getDB(), id)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterGetByID: %w", err))
+	}
+	writer.DefaultTags = defaults
+
+	return &writer, nil
+}
+
+// WriterGetBySlug ...
+func WriterGetBySlug(slug string) (*model.Writer, error) {
+	defer logSlowQuery("WriterGetBySlug", time.Now())
+
+	// writers テーブルからスラッグに一致する筆者データを一件取得します。
+	query := buildQuery(`SELECT ` + writerColumns + ` FROM writers WHERE slug = ?;`)
+	var writer model.Writer
+	if err := getDB().Get(&writer, query, slug); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterGetBySlug: %w", err))
+	}
+
+	// 筆者 ID を基に複数の記事データを取得します。
+	articles, err := ArticleListByWriterID(writer.ID)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterGetBySlug: %w", err))
+	}
+	writer.Articles = articles
+
+	// 記事の作成時に自動で付けるタグ名を取得します。
+	defaults, err := writerDefaultTagNames(getDB(), writer.ID)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterGetBySlug: %w", err))
+	}
+	writer.DefaultTags = defaults
+
+	return &writer, nil
+}
+
+// WriterCreate ...
+func WriterCreate(writer *model.Writer) (sql.Result, error) {
+	defer logSlowQuery("WriterCreate", time.Now())
+
+	// タイムゾーンが指定されている場合は、存在するタイムゾーンかをチェックします。
+	if err := validateTimezone(writer.Timezone); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterCreate: %w", err))
+	}
+
+	// スラッグを名前から生成した場合は、同時に同じスラッグの筆者が作成されて一意制約違反になった際に一度だけ生成し直します。
+	generated := writer.Slug == ""
+	res, err := insertWriter(writer)
+	if generated && isDuplicateWriterSlug(err) {
+		writer.Slug = ""
+		res, err = insertWriter(writer)
+	}
+	if err != nil {
+		// メールアドレスやスラッグが登録済みの場合は専用のエラーを返却します。
+		if isDuplicateEmail(err) {
+			return nil, ErrEmailTaken
+		}
+		if isDuplicateWriterSlug(err) {
+			return nil, ErrSlugTaken
+		}
+		return nil, ClassifyError(fmt.Errorf("WriterCreate: %w", err))
+	}
+
+	return res, nil
+}
+
+// insertWriter は一つのトランザクションで筆者データを作成し、作成されたレコードの ID を構造体にセットします。
+// スラッグが指定されていない場合は名前から生成します。
+func insertWriter(writer *model.Writer) (sql.Result, error) {
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	if writer.Slug == "" {
+		slug, err := uniqueSlug(tx, "writers", slugify(writer.Name, "writer"))
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		writer.Slug = slug
+	}
+
+	query := buildQuery(`INSERT INTO writers (name, slug, email, timezone, avatar_url) VALUES (:name, :slug, :email, :timezone, :avatar_url);`)
+	res, err := tx.NamedExec(query, writer)
+	if err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	writer.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// WriterUpdate ...
+func WriterUpdate(writer *model.Writer) (sql.Result, error) {
+	defer logSlowQuery("WriterUpdate", time.Now())
+
+	// タイムゾーンが指定されている場合は、存在するタイムゾーンかをチェックします。
+	if err := validateTimezone(writer.Timezone); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterUpdate: %w", err))
+	}
+
+	query := buildQuery(`UPDATE writers
+	SET name = :name,
+		email = :email,
+		timezone = :timezone,
+		avatar_url = :avatar_url
+	WHERE id = :id;`)
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterUpdate: %w", err))
+	}
+
+	res, err := tx.NamedExec(query, writer)
+	if err != nil {
+		// エラーが発生した場合はロールバックします。
+		tx.Rollback()
+
+		// メールアドレスが他の筆者に登録済みの場合は専用のエラーを返却します。
+		if isDuplicateEmail(err) {
+			return nil, ErrEmailTaken
+		}
+		return nil, ClassifyError(fmt.Errorf("WriterUpdate: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterUpdate: %w", err))
+	}
+
+	return res, nil
+}
+
+// validateTimezone はタイムゾーンの名前（Asia/Tokyo など）が存在するかをチェックします。
+// 空の場合は UTC として扱うため、エラーにしません。
+func validateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// isDuplicateEmail はエラーがメールアドレスの一意制約違反かどうかを判定します。
+func isDuplicateEmail(err error) bool {
+	return isDuplicateEntry(err, writersEmailIndex, "writers.email")
+}
+
+// isDuplicateWriterSlug はエラーが筆者のスラッグの一意制約違反かどうかを判定します。
+func isDuplicateWriterSlug(err error) bool {
+	return isDuplicateEntry(err, writersSlugIndex, "writers.slug")
+}
+
+// isDuplicateEntry はエラーが指定したインデックスの一意制約違反かどうかを判定します。
+// MySQL の場合はエラー番号 1062 とインデックス名、SQLite の場合はエラーメッセージのカラム名で判定します。
+func isDuplicateEntry(err error, index, column string) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDuplicateEntry &&
+			strings.Contains(mysqlErr.Message, index)
+	}
+
+	return strings.Contains(err.Error(), "UNIQUE constraint failed: "+column)
+}
+
+// WriterDeleteCascade ...
+func WriterDeleteCascade(writerID int) (int, error) {
+	defer logSlowQuery("WriterDeleteCascade", time.Now())
+
+	// 筆者の記事に紐づくデータから順に削除していき、最後に筆者データを削除します。
+	// 外部キー制約があるため、参照している側のテーブルから削除する必要があります。
+	// 記事に紐づくデータは、記事を削除する他の処理と対象のテーブルがずれないよう deleteArticleDependents() で削除します。
+	queries := []string{
+		buildQuery(`DELETE FROM autosaves WHERE writer_id = ?;`),
+		buildQuery(`DELETE FROM follows WHERE follower_id = ?;`),
+		buildQuery(`DELETE FROM follows WHERE writer_id = ?;`),
+		buildQuery(`DELETE FROM writer_default_tags WHERE writer_id = ?;`),
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+	}
+
+	// 削除する間に記事が追加・変更されないよう、筆者の記事を FOR UPDATE でロックしながら取得します。
+	var articleIDs []int
+	if err := tx.Select(&articleIDs, buildQuery(`SELECT id FROM articles WHERE writer_id = ? FOR UPDATE;`), writerID); err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+	}
+	if len(articleIDs) > 0 {
+		if err := deleteArticleDependents(tx, articleIDs); err != nil {
+			tx.Rollback()
+			return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+		}
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query, writerID); err != nil {
+			// エラーが発生した場合はロールバックします。
+			tx.Rollback()
+			return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+		}
+	}
+
+	// 筆者の記事を削除し、削除した件数を取得します。
+	res, err := tx.Exec(buildQuery(`DELETE FROM articles WHERE writer_id = ?;`), writerID)
+	if err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+	}
+
+	// 筆者データを削除します。
+	if _, err := tx.Exec(buildQuery(`DELETE FROM writers WHERE id = ?;`), writerID); err != nil {
+		tx.Rollback()
+		return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ClassifyError(fmt.Errorf("WriterDeleteCascade: %w", err))
+	}
+
+	return int(deleted), nil
+}
+
+// WriterNameMapByIDs ...
+func WriterNameMapByIDs(ids []int) (map[int]string, error) {
+	defer logSlowQuery("WriterNameMapByIDs", time.Now())
+
+	// 筆者名を格納するマップを生成します。
+	// マップのキーに筆者 ID、バリューに筆者名を格納します。
+	m := make(map[int]string, len(ids))
+
+	// 引数で渡ってきたスライスのサイズが 0 の場合は即時リターンします。
+	if len(ids) == 0 {
+		return m, nil
+	}
+
+	q1 := buildQuery(`SELECT id, name FROM writers WHERE id IN(?);`)
+
+	// IN 句を利用するクエリを作成するには sqlx パッケージの In() 関数を利用します。
+	q2, args, err := sqlx.In(q1, ids)
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterNameMapByIDs: %w", err))
+	}
+
+	var writers []*model.Writer
+	if err := getDB().Select(&writers, q2, args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterNameMapByIDs: %w", err))
+	}
+
+	// 取得したデータを map に格納し直します。
+	for _, writer := range writers {
+		m[writer.ID] = writer.Name
+	}
+
+	return m, nil
+}
+
+// WriterDashboard ...
+func WriterDashboard(writerID int) (*model.WriterDashboard, error) {
+	defer logSlowQuery("WriterDashboard", time.Now())
+
+	// 記事数・閲覧数・いいね数・コメント数をサブクエリで一回のクエリにまとめて集計します。
+	// 記事がない筆者でも NULL ではなく 0 になるように、SUM は COALESCE で初期値を指定します。
+	// ゴミ箱に入っている記事は集計の対象外です。
+	query := buildQuery(`SELECT
+		(SELECT COUNT(*) FROM articles
+			WHERE writer_id = :writer_id AND deleted_at IS NULL) AS post_count,
+		(SELECT COALESCE(SUM(views), 0) FROM articles
+			WHERE writer_id = :writer_id AND deleted_at IS NULL) AS total_views,
+		(SELECT COUNT(*) FROM article_likes AS l
+			INNER JOIN articles ON articles.id = l.article_id
+			WHERE articles.writer_id = :writer_id AND articles.deleted_at IS NULL) AS total_likes,
+		(SELECT COUNT(*) FROM comments
+			INNER JOIN articles ON articles.id = comments.article_id
+			WHERE articles.writer_id = :writer_id AND articles.deleted_at IS NULL) AS comment_count;`)
+
+	q, args, err := sqlx.Named(query, map[string]interface{}{"writer_id": writerID})
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterDashboard: %w", err))
+	}
+
+	db := getDB()
+	var dashboard model.WriterDashboard
+	if err := db.Get(&dashboard, db.Rebind(q), args...); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterDashboard: %w", err))
+	}
+
+	return &dashboard, nil
+}
+
+// writerProfileArticlesLimit はプロフィールページに表示する記事の最大件数です。
+const writerProfileArticlesLimit = 20
+
+// WriterGetWithArticles ...
+func WriterGetWithArticles(id int) (*model.Writer, error) {
+	defer logSlowQuery("WriterGetWithArticles", time.Now())
+
+	// writers テーブルから筆者データを一件取得します。
+	q1 := buildQuery(`SELECT ` + writerColumns + ` FROM writers WHERE id = ?;`)
+	var writer model.Writer
+	if err := getDB().Get(&writer, q1, id); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterGetWithArticles: %w", err))
+	}
+
+	// 筆者の公開中の記事を新しい順に取得します。
+	q2 := buildQuery(`SELECT ` + articleColumns + `
+	FROM articles
+	WHERE writer_id = ?
+	AND status = ?
+	AND deleted_at IS NULL
+	ORDER BY id desc
+	LIMIT ?;`)
+	articles := make([]*model.Article, 0, writerProfileArticlesLimit)
+	if err := getDB().Select(&articles, q2, id, model.ArticleStatusPublished, writerProfileArticlesLimit); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterGetWithArticles: %w", err))
+	}
+
+	// 記事データを筆者の構造体のフィールドに格納します。
+	writer.Articles = articles
+
 	return &writer, nil
 }
+
+// WriterListByTotalViews ...
+func WriterListByTotalViews(limit int) ([]*model.Writer, error) {
+	defer logSlowQuery("WriterListByTotalViews", time.Now())
+
+	// 取得件数が 0 以下の場合は空のスライスを返却します。
+	if limit <= 0 {
+		return []*model.Writer{}, nil
+	}
+
+	// 筆者ごとに記事の閲覧数を合計し、合計の多い順に取得します。
+	// 記事のない筆者も LEFT JOIN で 0 として集計されるため、上位の筆者が足りない場合のみ含まれます。
+	query := buildQuery(`SELECT
+		` + writerColumns + `,
+		COALESCE(SUM(articles.views), 0) AS total_views
+	FROM writers
+	LEFT JOIN articles ON articles.writer_id = writers.id AND articles.deleted_at IS NULL
+	GROUP BY writers.id
+	ORDER BY total_views desc, writers.id
+	LIMIT ?;`)
+
+	writers := make([]*model.Writer, 0, limit)
+	if err := getDB().Select(&writers, query, limit); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterListByTotalViews: %w", err))
+	}
+
+	return writers, nil
+}
+
+// WriterListContributors ...
+func WriterListContributors() ([]*model.Writer, error) {
+	defer logSlowQuery("WriterListContributors", time.Now())
+
+	// 公開中の記事が一件以上ある筆者を名前の順に取得します。
+	// 下書きやゴミ箱に入っている記事しかない筆者は含めません。
+	query := buildQuery(`SELECT ` + writerColumns + `
+	FROM writers
+	WHERE EXISTS (
+		SELECT 1 FROM articles
+		WHERE articles.writer_id = writers.id
+		AND articles.status = ?
+		AND articles.deleted_at IS NULL
+	)
+	ORDER BY name, id;`)
+
+	writers := []*model.Writer{}
+	if err := getDB().Select(&writers, query, model.ArticleStatusPublished); err != nil {
+		return nil, ClassifyError(fmt.Errorf("WriterListContributors: %w", err))
+	}
+
+	return writers, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"go-tech-blog/model"
+	"testing"
+)
+
+func TestWriterDeleteCascade(t *testing.T) {
+	d := NewTestDB(t)
+
+	writer := &model.Writer{Name: "writer", Email: "writer@example.com"}
+	if _, err := WriterCreate(writer); err != nil {
+		t.Fatalf("WriterCreate: %v", err)
+	}
+
+	// 冪等キー付きで作成した記事も、外部キー制約のエラーにならずに削除できることを確認します。
+	article := &model.Article{Title: "title", Body: "body", WriterID: writer.ID}
+	if _, err := ArticleCreateIdempotent(article, "key"); err != nil {
+		t.Fatalf("ArticleCreateIdempotent: %v", err)
+	}
+	tag, err := TagCreate("go")
+	if err != nil {
+		t.Fatalf("TagCreate: %v", err)
+	}
+	if err := ArticleSetTags(article.ID, []int{tag.ID}); err != nil {
+		t.Fatalf("ArticleSetTags: %v", err)
+	}
+
+	deleted, err := WriterDeleteCascade(writer.ID)
+	if err != nil {
+		t.Fatalf("WriterDeleteCascade: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("WriterDeleteCascade() = %d, want 1", deleted)
+	}
+
+	for _, table := range []string{"articles", "articles_tags", "article_idempotency_keys", "writers"} {
+		var count int
+		if err := d.Get(&count, "SELECT COUNT(*) FROM "+table+";"); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%s = %d rows, want 0", table, count)
+		}
+	}
+}
+
+func TestWriterDeleteCascadeWithoutArticles(t *testing.T) {
+	NewTestDB(t)
+
+	writer := &model.Writer{Name: "writer", Email: "writer@example.com"}
+	if _, err := WriterCreate(writer); err != nil {
+		t.Fatalf("WriterCreate: %v", err)
+	}
+
+	deleted, err := WriterDeleteCascade(writer.ID)
+	if err != nil {
+		t.Fatalf("WriterDeleteCascade: %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("WriterDeleteCascade() = %d, want 0", deleted)
+	}
+}
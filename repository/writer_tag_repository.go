@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"fmt"
+	"go-tech-blog/model"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WriterSetDefaultTags ...
+func WriterSetDefaultTags(writerID int, tags []string) error {
+	defer logSlowQuery("WriterSetDefaultTags", time.Now())
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return ClassifyError(fmt.Errorf("WriterSetDefaultTags: %w", err))
+	}
+
+	// 筆者のデフォルトのタグをすべて外してから、指定されたタグを設定し直します。
+	if _, err := tx.Exec(buildQuery(`DELETE FROM writer_default_tags WHERE writer_id = ?;`), writerID); err != nil {
+		tx.Rollback()
+		return ClassifyError(fmt.Errorf("WriterSetDefaultTags: %w", err))
+	}
+
+	// まだ存在しないタグは作成してから設定します。
+	// 大文字と小文字だけが異なるタグ名は一意制約で同じタグになるため、重複は ON DUPLICATE KEY UPDATE で無視して一件のみ設定します。
+	// INSERT IGNORE では外部キーのエラーも無視されてしまうため利用しません。
+	q := buildQuery(`INSERT INTO writer_default_tags (writer_id, tag_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE tag_id = tag_id;`)
+	for _, name := range mergeTagNames(tags) {
+		tagID, err := ensureTag(tx, name)
+		if err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("WriterSetDefaultTags: %w", err))
+		}
+		if _, err := tx.Exec(q, writerID, tagID); err != nil {
+			tx.Rollback()
+			return ClassifyError(fmt.Errorf("WriterSetDefaultTags: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ClassifyError(fmt.Errorf("WriterSetDefaultTags: %w", err))
+	}
+	return nil
+}
+
+// ArticleCreateWithTags ...
+func ArticleCreateWithTags(article *model.Article, tags []string) (*model.Article, error) {
+	defer logSlowQuery("ArticleCreateWithTags", time.Now())
+
+	// 未指定の項目に初期値を設定し、保存する前に記事データの内容をチェックします。
+	if err := prepareArticleCreate(article); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+	}
+
+	// トランザクションを開始します。
+	tx, err := beginTx()
+	if err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+	}
+
+	if _, err := insertArticle(tx, article); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+	}
+
+	// 指定されたタグに筆者のデフォルトのタグを加えます。
+	defaults, err := writerDefaultTagNames(tx, article.WriterID)
+	if err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+	}
+
+	// まだ存在しないタグは作成してから記事に紐づけます。
+	// 同じタグになる名前が複数あっても一件のみ紐づけるよう、重複は ON DUPLICATE KEY UPDATE で無視します。
+	q := buildQuery(`INSERT INTO articles_tags (article_id, tag_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE tag_id = tag_id;`)
+	for _, name := range mergeTagNames(tags, defaults) {
+		tagID, err := ensureTag(tx, name)
+		if err != nil {
+			tx.Rollback()
+			return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+		}
+		if _, err := tx.Exec(q, article.ID, tagID); err != nil {
+			tx.Rollback()
+			return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+		}
+	}
+
+	// 一覧画面で JOIN せずにタグを表示できるように、タグ名のキャッシュを更新します。
+	if err := refreshTagCache(tx, article.ID); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+	}
+
+	// 紐づけたタグを構造体に設定します。
+	tagList := []*model.Tag{}
+	q2 := buildQuery(`SELECT tags.*
+	FROM tags
+	INNER JOIN articles_tags AS at ON at.tag_id = tags.id
+	WHERE at.article_id = ?
+	ORDER BY tags.id;`)
+	if err := tx.Select(&tagList, q2, article.ID); err != nil {
+		tx.Rollback()
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+	}
+	article.Tags = tagList
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(fmt.Errorf("ArticleCreateWithTags: %w", err))
+	}
+
+	return article, nil
+}
+
+// writerDefaultTagNames は筆者のデフォルトのタグ名を取得します。
+// 筆者が指定されていない（0 の）場合は空のスライスを返却します。
+func writerDefaultTagNames(q sqlx.Queryer, writerID int) ([]string, error) {
+	names := []string{}
+	if writerID == 0 {
+		return names, nil
+	}
+
+	query := buildQuery(`SELECT tags.name
+	FROM writer_default_tags AS wt
+	INNER JOIN tags ON tags.id = wt.tag_id
+	WHERE wt.writer_id = ?
+	ORDER BY tags.id;`)
+	if err := sqlx.Select(q, &names, query, writerID); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// mergeTagNames は複数のタグ名のリストを一つにまとめます。
+// 前後の空白を取り除き、空のタグ名は除きます。
+// 大文字と小文字だけが異なるタグ名は同じタグとして扱い、最初に現れたものを残します。
+func mergeTagNames(lists ...[]string) []string {
+	merged := []string{}
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, name := range list {
+			name = strings.TrimSpace(name)
+			key := strings.ToLower(name)
+			if name == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
@@ -45,6 +45,11 @@ document.addEventListener('DOMContentLoaded', () => {
     });
   }
 
+  // 次のページがない場合はもっとみるボタンが表示されないため、処理を終了します。
+  if (!moreBtn) {
+    return;
+  }
+
   // もっとみるボタンにイベントリスナーを設定します。
   moreBtn.addEventListener('click', event => {
     event.preventDefault();
@@ -61,9 +66,11 @@ document.addEventListener('DOMContentLoaded', () => {
 
     // Fetch API を利用して非同期リクエストを実行します。
     let statusCode;
+    let hasNext;
     fetch(`/api/articles?cursor=${cursor}`)
       .then(res => {
         statusCode = res.status;
+        hasNext = res.headers.get('X-Has-Next');
         return res.json();
       })
       .then(data => {
@@ -93,7 +100,7 @@ document.addEventListener('DOMContentLoaded', () => {
             frag.querySelector('article').classList.add(`articles__item-${article.id}`);
             frag.querySelector('.articles__item').setAttribute('href', `/articles/${article.id}`);
             frag.querySelector('.articles__item-title').textContent = article.title;
-            frag.querySelector('.articles__item-date').textContent = article.created.split('T')[0]; //+年-月-日のみを抽出
+            frag.querySelector('.articles__item-date').textContent = article.published_at.split('T')[0]; //+年-月-日のみを抽出
 
             // デリートボタンに対して、カスタムデータ属性やイベントリスナーを設定します。
             const deleteBtnElm = frag.querySelector('.articles__item-delete');
@@ -112,6 +119,11 @@ document.addEventListener('DOMContentLoaded', () => {
 
           // 記事一覧の HTML 要素の子要素に記事リストのフラグメントを追加して画面に表示します。
           articles.appendChild(fragment);
+
+          // 次のページがない場合は、もっとみるボタンを画面から削除します。
+          if (hasNext === 'false') {
+            moreBtn.remove();
+          }
         }
       })
       .catch(err => console.error(err));